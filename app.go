@@ -0,0 +1,392 @@
+package caleyi
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qinuoyun/caleyi/common"
+	"github.com/qinuoyun/caleyi/utils/ci"
+	"gorm.io/gorm"
+)
+
+// App 可嵌入的应用实例，封装路由、数据库与 HTTP 监听的完整生命周期。
+// 所有步骤均返回错误而非退出进程，便于在测试中构建引擎。
+//
+// 限制：数据库（ci.D / ci.MC）、就绪状态、租户连接池、异步任务与停机钩子均为进程级全局状态，
+// 同一进程中同时只能存在一个 App：已有 App 未 Stop 时再次 New 返回错误。
+//
+//	app, err := caleyi.New(caleyi.WithAddr(":8080"))
+//	if err != nil { ... }
+//	if err := app.Migrate(); err != nil { ... }
+//	if err := app.Start(ctx); err != nil { ... }
+//	defer app.Stop(ctx)
+type App struct {
+	addr            string
+	shutdownTimeout time.Duration
+	routesFile      string
+
+	db      *gorm.DB
+	noDB    bool // 不连接数据库（仅构建路由，如 routes 命令）
+	ownDB   bool // 连接由 App 自行创建时，Stop 负责关闭
+	engine  *gin.Engine
+	servers []*http.Server
+	errCh   chan error
+
+	mu      sync.Mutex
+	started bool
+}
+
+var (
+	activeMu  sync.Mutex
+	activeApp *App // 当前进程中的 App，见 App 的限制说明
+)
+
+// acquireApp 占用进程唯一的 App 位置
+func acquireApp(a *App) error {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	if activeApp != nil {
+		return errors.New("同一进程中已存在未停止的 App（数据库、停机钩子等为进程级全局状态），请先调用 Stop")
+	}
+	activeApp = a
+	return nil
+}
+
+// releaseApp 释放 App 位置（New 失败或 Stop 后）
+func releaseApp(a *App) {
+	activeMu.Lock()
+	if activeApp == a {
+		activeApp = nil
+	}
+	activeMu.Unlock()
+}
+
+// Option App 可选配置
+type Option func(*App)
+
+// WithAddr 指定监听地址，默认 ":" + app.app_port
+func WithAddr(addr string) Option {
+	return func(a *App) {
+		a.addr = addr
+	}
+}
+
+// WithDB 使用外部提供的数据库连接（如测试中的 SQLite 内存库），跳过按配置建连；Stop 时不会关闭该连接
+func WithDB(db *gorm.DB) Option {
+	return func(a *App) {
+		a.db = db
+	}
+}
+
+// WithoutDB 不连接数据库，仅构建路由（用于 routes 命令或不涉及数据库的测试），此时 Migrate 返回错误
+func WithoutDB() Option {
+	return func(a *App) {
+		a.noDB = true
+	}
+}
+
+// WithShutdownTimeout 指定停机排空超时时间，默认取 app.shutdown_timeout
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(a *App) {
+		a.shutdownTimeout = d
+	}
+}
+
+// WithRoutesFile 指定路由清单（JSON）文件路径，传空字符串表示不写文件，默认 runtime/app/routes.json
+func WithRoutesFile(path string) Option {
+	return func(a *App) {
+		a.routesFile = path
+	}
+}
+
+// New 创建应用实例：初始化中间件、连接数据库、初始化服务并构建路由。
+// 不执行数据库迁移（见 Migrate），也不监听端口（见 Start）。已有 App 未 Stop 时返回错误。
+func New(opts ...Option) (app *App, err error) {
+	a := &App{
+		routesFile: "runtime/app/routes.json",
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if err := acquireApp(a); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if a.ownDB && a.db != nil {
+				if sqlDB, dbErr := a.db.DB(); dbErr == nil {
+					_ = sqlDB.Close()
+				}
+			}
+			releaseApp(a)
+		}
+	}()
+	if a.addr == "" {
+		a.addr = ":" + ci.C("app.app_port")
+	}
+	if a.shutdownTimeout <= 0 {
+		a.shutdownTimeout = ci.ShutdownTimeout()
+	}
+
+	//初始化中间件
+	if err := common.InitMiddleware(); err != nil {
+		return nil, err
+	}
+
+	//初始化模型
+	if a.db == nil && !a.noDB {
+		db, err := common.OpenDB()
+		if err != nil {
+			return nil, err
+		}
+		a.db = db
+		a.ownDB = true
+	}
+	if a.db != nil {
+		// 外部提供的连接同样启用租户隔离（OpenDB 已注册时跳过）
+		if err := ci.UseTenantPlugin(a.db); err != nil {
+			return nil, err
+		}
+		// tenant.mode 为 schema / database 时按外部连接的数据库类型打开租户存储
+		if !a.ownDB {
+			if err := common.UseTenantStores(a.db); err != nil {
+				return nil, err
+			}
+		}
+		// 将 DB 实例设置到 ci 包中
+		ci.SetDB(a.db)
+	}
+
+	//初始化服务
+	common.InitServer()
+
+	//加载路由
+	r, err := common.NewRouter()
+	if err != nil {
+		return nil, fmt.Errorf("初始化路由失败: %w", err)
+	}
+	for _, fn := range ci.GetGinAfterRouterHooks() {
+		fn(r)
+	}
+	a.engine = r
+
+	if a.routesFile != "" {
+		if err := common.WriteRouteManifest(r, a.routesFile); err != nil {
+			return nil, fmt.Errorf("写入路由清单失败: %w", err)
+		}
+	}
+	return a, nil
+}
+
+// Handler 返回应用的 HTTP 处理器，可直接用于 httptest 或挂载到其它 http.Server
+func (a *App) Handler() http.Handler {
+	return a.engine
+}
+
+// Engine 返回底层 gin.Engine
+func (a *App) Engine() *gin.Engine {
+	return a.engine
+}
+
+// DB 返回应用使用的数据库连接
+func (a *App) DB() *gorm.DB {
+	return a.db
+}
+
+// Migrate 迁移所有已注册的模块与插件模板
+func (a *App) Migrate() error {
+	if a.db == nil {
+		return errors.New("未连接数据库，无法执行迁移")
+	}
+	// 迁移期间 /readyz 返回未就绪，失败时保持未就绪
+	ci.SetReady(false, "migrating")
+	if err := common.Migrate(a.db); err != nil {
+		ci.SetReady(false, err.Error())
+		return err
+	}
+	ci.SetReady(true, "")
+	return nil
+}
+
+// Start 执行启动钩子并在后台开始监听（按 [tls] 配置决定 HTTP/HTTPS 及跳转监听），不阻塞。
+// 端口绑定失败时直接返回错误，全部监听绑定成功后才标记就绪；运行过程中的错误通过 Err() 获取。
+func (a *App) Start(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.started {
+		return errors.New("应用已启动")
+	}
+
+	//执行启动钩子
+	if err := ci.RunOnStartHooks(ctx); err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:    a.addr,
+		Handler: a.engine,
+	}
+	servers := []*http.Server{srv}
+
+	tlsConfig, err := ci.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLS 配置错误: %w", err)
+	}
+	if tlsConfig != nil {
+		srv.TLSConfig = tlsConfig
+		if !ci.TLSHTTP2Enabled() {
+			// 非 nil 的空 map 会关闭 net/http 内置的 HTTP/2 支持
+			srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+		}
+		// 可选：HTTP → HTTPS 跳转监听
+		if redirectPort := ci.C("tls.redirect_port"); redirectPort != "" {
+			port := a.addr[strings.LastIndex(a.addr, ":")+1:]
+			servers = append(servers, &http.Server{
+				Addr:    ":" + redirectPort,
+				Handler: ci.HTTPSRedirectHandler(port),
+			})
+		}
+	}
+
+	// 同步绑定全部监听（端口占用等错误直接返回），全部成功后再启动服务并标记就绪
+	listeners := make([]net.Listener, 0, len(servers))
+	for _, s := range servers {
+		ln, err := net.Listen("tcp", s.Addr)
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return fmt.Errorf("监听 %s 失败: %w", s.Addr, err)
+		}
+		listeners = append(listeners, ln)
+	}
+
+	a.servers = servers
+	a.errCh = make(chan error, len(servers))
+	for i, s := range servers {
+		go func(s *http.Server, ln net.Listener) {
+			var err error
+			if s.TLSConfig != nil {
+				fmt.Printf("[boot] HTTPS 服务监听 %s\n", s.Addr)
+				// 证书由 TLSConfig.GetCertificate 提供，此处文件参数留空
+				err = s.ServeTLS(ln, "", "")
+			} else {
+				fmt.Printf("[boot] HTTP 服务监听 %s\n", s.Addr)
+				err = s.Serve(ln)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				a.errCh <- err
+			}
+		}(s, listeners[i])
+	}
+	a.started = true
+	ci.SetReady(true, "")
+	return nil
+}
+
+// Err 返回监听错误通道，Start 之前调用返回 nil
+func (a *App) Err() <-chan error {
+	return a.errCh
+}
+
+// Stop 按顺序停机：标记未就绪并等待 app.shutdown_delay → 排空 HTTP 请求 → 等待异步任务 → 逆序执行停机钩子 → 关闭数据库连接。
+// ctx 未设置截止时间时使用 shutdown timeout。未 Start 的 App 同样需要 Stop 以释放进程中的 App 位置。
+func (a *App) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// 停机开始即标记未就绪，/readyz 返回 503，负载均衡不再转发新流量
+	ci.SetReady(false, "shutting down")
+
+	// 摘流等待：监听保持开启，直到就绪探针观察到 503（未启动监听时无需等待）
+	if delay := ci.ShutdownDelay(); delay > 0 && len(a.servers) > 0 {
+		fmt.Printf("[shutdown] 等待 %v 摘除流量\n", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.shutdownTimeout)
+		defer cancel()
+	}
+
+	var firstErr error
+	for _, srv := range a.servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			fmt.Printf("[shutdown] HTTP 服务 %s 排空超时: %v\n", srv.Addr, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	a.servers = nil
+	a.started = false
+
+	if err := ci.WaitAsync(ctx); err != nil {
+		fmt.Printf("[shutdown] 等待异步任务超时: %v\n", err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := ci.RunOnShutdownHooks(ctx); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	// 租户连接池（schema / database 模式）由框架打开，无论共享连接是否为外部提供均在此关闭
+	if err := ci.CloseTenantStores(); err != nil {
+		fmt.Printf("[shutdown] 关闭租户数据库连接失败: %v\n", err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if a.ownDB && a.db != nil {
+		if sqlDB, err := a.db.DB(); err == nil {
+			if err := sqlDB.Close(); err != nil {
+				fmt.Printf("[shutdown] 关闭数据库连接失败: %v\n", err)
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+	}
+	releaseApp(a)
+	fmt.Println("[shutdown] 服务已停止")
+	return firstErr
+}
+
+// Run 启动服务并阻塞，直到收到 SIGINT/SIGTERM 或监听出错，随后优雅停机
+func (a *App) Run() error {
+	if err := a.Start(context.Background()); err != nil {
+		return errors.Join(err, a.Stop(context.Background()))
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	var runErr error
+	select {
+	case sig := <-quit:
+		fmt.Printf("[shutdown] 收到信号 %v，开始优雅停机\n", sig)
+	case runErr = <-a.errCh:
+		fmt.Printf("[shutdown] HTTP 服务异常退出: %v\n", runErr)
+	}
+
+	if err := a.Stop(context.Background()); err != nil && runErr == nil {
+		runErr = err
+	}
+	return runErr
+}
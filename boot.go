@@ -1,11 +1,9 @@
 package caleyi
 
 import (
-	"fmt"
 	"log"
-	"strings"
+	"os"
 
-	"github.com/qinuoyun/caleyi/common"
 	"github.com/qinuoyun/caleyi/utils/ci"
 )
 
@@ -16,39 +14,19 @@ func init() {
 	}
 }
 
-// BootStart 启动 HTTP 服务。可选：在业务包 init() 中调用 ci.BinAgentRoutes 注入 Agent API（默认前缀 /agent，见 common.bindAgentHTTPRoutes）。
+// BootStart 启动入口。可选：在业务包 init() 中调用 ci.BinAgentRoutes 注入 Agent API（默认前缀 /agent，见 common.bindAgentHTTPRoutes）。
+// 启动 HTTP 服务：New → Migrate（app.auto_migrate=false 时跳过）→ Run；不解析命令行参数，
+// 仅当首个参数为已注册的子命令（或 help）时按 Execute 分发，其余参数（自有 flag、go test 参数等）一律忽略。
+// 收到 SIGINT/SIGTERM 后优雅停机：停止接收新连接，在 app.shutdown_timeout 内等待进行中的请求与 ci.Go 异步任务结束，
+// 再逆序执行 ci.BinOnShutdown 注册的钩子并关闭数据库连接。任一步骤出错时退出进程。
 func BootStart() {
-
-	//初始化中间件
-	common.InitMiddleware()
-
-	//初始化模型
-	common.InitModule()
-
-	//初始化服务
-	common.InitServer()
-
-	//加载路由
-	r := common.InitRouter()
-
-	for _, fn := range ci.GetGinAfterRouterHooks() {
-		fn(r)
+	var err error
+	if args := os.Args[1:]; len(args) > 0 && isCommand(args[0]) {
+		err = Execute(args)
+	} else {
+		err = serve(false)
 	}
-
-	routes := ""
-	for _, route := range r.Routes() {
-		if !strings.Contains(route.Path, "/admin/") && route.Path != "/" && !strings.Contains(route.Path, "/*filepath") {
-			routes = routes + fmt.Sprintf("%v\n", route.Path)
-		}
-	}
-	filePath := "runtime/app/routers.txt"
-	err := ci.WriteToFile(filePath, routes)
-	if err != nil {
-		return
-	}
-
-	err = r.Run(":" + ci.C("app.app_port"))
 	if err != nil {
-		return
+		log.Fatalf("%v", err)
 	}
 }
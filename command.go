@@ -0,0 +1,202 @@
+package caleyi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qinuoyun/caleyi/common"
+	"github.com/qinuoyun/caleyi/utils/ci"
+)
+
+// 内置子命令，插件可通过 ci.BinCommand 注册同名命令覆盖
+func init() {
+	ci.BinCommand("serve", "启动 HTTP 服务（默认命令），--no-migrate 跳过自动迁移", serveCommand)
+	ci.BinCommand("migrate", "仅执行数据库迁移，不启动 HTTP 服务", migrateCommand)
+	ci.BinCommand("routes", "输出路由列表，--json 以 JSON 格式输出", routesCommand)
+	ci.BinCommand("config", "配置检查：config check [--no-db]", configCommand)
+}
+
+// Execute 分发命令行子命令，供自定义 CLI 入口使用（main 中 caleyi.Execute(os.Args[1:])）：
+//
+//	./server                 等价于 ./server serve
+//	./server serve           启动服务
+//	./server migrate         执行迁移
+//	./server routes --json   输出路由
+//	./server config check    检查配置
+//	./server help            查看所有命令
+func Execute(args []string) error {
+	// 无参数或首个参数为选项时，视为 serve（兼容旧的启动方式）
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return serveCommand(args)
+	}
+	name := args[0]
+	if name == "help" || name == "--help" || name == "-h" {
+		printUsage()
+		return nil
+	}
+	cmd, ok := ci.GetCommand(name)
+	if !ok {
+		printUsage()
+		return fmt.Errorf("未知命令: %s", name)
+	}
+	return cmd.Run(args[1:])
+}
+
+// printUsage 输出所有已注册的子命令
+func printUsage() {
+	fmt.Printf("用法: %s <command> [args]\n\n可用命令:\n", os.Args[0])
+	for _, cmd := range ci.GetCommandsList() {
+		fmt.Printf("  %-10s %s\n", cmd.Name, cmd.Usage)
+	}
+	fmt.Printf("  %-10s %s\n", "help", "查看所有命令")
+}
+
+// serveCommand 启动 HTTP 服务；app.auto_migrate=false 或 --no-migrate 时不自动迁移
+func serveCommand(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	noMigrate := fs.Bool("no-migrate", false, "跳过自动迁移")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return serve(*noMigrate)
+}
+
+// isCommand 判断参数是否为已注册的子命令或 help（不含 -h 等选项，避免与业务自有 flag 冲突）
+func isCommand(name string) bool {
+	if name == "help" {
+		return true
+	}
+	_, ok := ci.GetCommand(name)
+	return ok
+}
+
+// serve 创建 App，按 app.auto_migrate 迁移后启动服务并阻塞至停机
+func serve(noMigrate bool) error {
+	app, err := New()
+	if err != nil {
+		return err
+	}
+	if !noMigrate && ci.C("app.auto_migrate") != "false" {
+		if err := app.Migrate(); err != nil {
+			// 释放数据库、租户连接与进程中的 App 位置
+			return errors.Join(err, app.Stop(context.Background()))
+		}
+	}
+	return app.Run()
+}
+
+// migrateCommand 连接数据库并迁移所有已注册模块，不构建路由、不监听端口
+func migrateCommand(args []string) error {
+	db, err := common.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}()
+	ci.SetDB(db)
+	if err := common.Migrate(db); err != nil {
+		return err
+	}
+	fmt.Println("[migrate] 迁移完成")
+	return nil
+}
+
+// routesCommand 构建路由（不连接数据库）并输出
+func routesCommand(args []string) error {
+	fs := flag.NewFlagSet("routes", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// 关闭 gin 的调试输出，避免混入路由列表
+	gin.SetMode(gin.ReleaseMode)
+	app, err := New(WithoutDB(), WithRoutesFile(""))
+	if err != nil {
+		return err
+	}
+	items := common.BuildRouteManifest(app.Engine())
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(items)
+	}
+	for _, item := range items {
+		fmt.Printf("%-7s %-60s %-8s %s\n", item.Method, item.Path, item.Group, item.Handler)
+	}
+	return nil
+}
+
+// configCommand 配置相关命令，目前仅支持 check
+func configCommand(args []string) error {
+	if len(args) == 0 || args[0] != "check" {
+		return errors.New("用法: config check [--no-db]")
+	}
+	fs := flag.NewFlagSet("config check", flag.ContinueOnError)
+	noDB := fs.Bool("no-db", false, "不尝试连接数据库")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	fmt.Printf("[config] 配置文件: %s\n", ci.ConfigPath())
+	var problems []string
+
+	if port, err := strconv.Atoi(ci.C("app.app_port")); err != nil || port <= 0 || port > 65535 {
+		problems = append(problems, "app.app_port 必须是 1-65535 之间的端口号")
+	}
+
+	switch sqlType := ci.C("app.app_sql"); sqlType {
+	case "mysql":
+		problems = append(problems, requireKeys("mysql.ip", "mysql.port", "mysql.user", "mysql.database")...)
+	case "postgre", "postgres":
+		problems = append(problems, requireKeys("pgsql.ip", "pgsql.port", "pgsql.user", "pgsql.database")...)
+	case "sqlite":
+	default:
+		problems = append(problems, fmt.Sprintf("app.app_sql 不支持: %q（仅支持 mysql/postgre/sqlite）", sqlType))
+	}
+
+	if _, err := ci.TLSConfig(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if !*noDB && len(problems) == 0 {
+		db, err := common.OpenDB()
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else if sqlDB, err := db.DB(); err == nil {
+			if err := sqlDB.Ping(); err != nil {
+				problems = append(problems, "数据库连接失败: "+err.Error())
+			}
+			_ = sqlDB.Close()
+		}
+	}
+
+	if len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Printf("  ✗ %s\n", p)
+		}
+		return fmt.Errorf("配置检查未通过，共 %d 项问题", len(problems))
+	}
+	fmt.Println("[config] 配置检查通过")
+	return nil
+}
+
+// requireKeys 检查配置项非空，返回缺失项说明
+func requireKeys(keys ...string) []string {
+	var missing []string
+	for _, key := range keys {
+		if strings.TrimSpace(ci.C(key)) == "" {
+			missing = append(missing, key+" 未配置")
+		}
+	}
+	return missing
+}
@@ -0,0 +1,291 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qinuoyun/caleyi/utils/ci"
+)
+
+// bindFrontendRoutes 为每个子前端项目注册 GET 前缀/*any 路由，解决 history 刷新问题。
+// 根项目（前缀 /）不在此注册，由 NoRoute 兜底（见 serveRootFrontend）。
+func bindFrontendRoutes(R *gin.Engine, mounts []ci.FrontendMount) {
+	for _, m := range mounts {
+		if m.Prefix == "/" {
+			continue
+		}
+		mount := m
+		R.GET(mount.Prefix+"/*any", func(c *gin.Context) {
+			if !serveFrontendFile(c, mount, c.Param("any")) {
+				c.Status(404)
+			}
+		})
+	}
+}
+
+// bindStaticRoutes 注册静态资源路由（GET/HEAD 前缀/*filepath），文件可来自磁盘目录或 fs.FS
+func bindStaticRoutes(R *gin.Engine, mounts []ci.StaticMount) {
+	for _, m := range mounts {
+		mount := m
+		handler := func(c *gin.Context) {
+			name := strings.TrimPrefix(path.Clean("/"+c.Param("filepath")), "/")
+			if name == "" {
+				name = "."
+			}
+			// 目录请求返回其中的 index.html，不输出目录列表
+			if info, err := fs.Stat(mount.FS, name); err == nil && info.IsDir() {
+				name = path.Join(name, "index.html")
+			}
+			if !serveFSFile(c, "static:"+mount.Prefix, mount.FS, name) {
+				c.Status(404)
+			}
+		}
+		R.GET(mount.Prefix+"/*filepath", handler)
+		R.HEAD(mount.Prefix+"/*filepath", handler)
+	}
+}
+
+// serveFrontendFile 在挂载点中查找并返回文件：
+//  1. 空路径或以 / 结尾 → 入口文件
+//  2. 文件存在 → 返回文件
+//  3. 静态资源后缀但文件不存在 → 未处理（由调用方返回 404）
+//  4. 其它路径 → 开启 history 时返回入口文件
+//
+// 返回 false 表示未找到可返回的内容。
+func serveFrontendFile(c *gin.Context, m ci.FrontendMount, filePath string) bool {
+	if filePath == "" || strings.HasSuffix(filePath, "/") {
+		return serveFSFile(c, "frontend:"+m.Prefix, m.FS, m.Options.Index)
+	}
+	// 去除开头的 / 并清理 ..，避免越出挂载目录
+	name := strings.TrimPrefix(path.Clean("/"+filePath), "/")
+	if info, err := fs.Stat(m.FS, name); err == nil && !info.IsDir() {
+		return serveFSFile(c, "frontend:"+m.Prefix, m.FS, name)
+	}
+	if isStaticExt(name, m.Options.StaticExts) || m.Options.DisableHistory {
+		return false
+	}
+	// 非静态资源请求，返回项目的入口文件（解决 history 刷新）
+	return serveFSFile(c, "frontend:"+m.Prefix, m.FS, m.Options.Index)
+}
+
+// serveFSFile 从文件系统返回文件内容，支持 Range、ETag/If-None-Match、预压缩文件与缓存策略：
+//   - 客户端支持且存在 .br / .gz 同名文件时直接返回压缩内容
+//   - 带内容哈希的文件名（如 index-4f8a1b2c.js）→ 长期缓存 immutable
+//   - .html（入口文件）→ no-cache，每次协商
+//   - 其它文件 → frontend.max_age 秒（默认 0 即 no-cache）
+//
+// mount 为挂载点标识（如 frontend:/admin），用作 ETag 缓存键。
+func serveFSFile(c *gin.Context, mount string, fsys fs.FS, name string) bool {
+	info, err := fs.Stat(fsys, name)
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	servedName, encoding := name, ""
+	hasCompressed := false
+	for _, enc := range precompressedEncodings {
+		if _, err := fs.Stat(fsys, name+enc.ext); err != nil {
+			continue
+		}
+		hasCompressed = true
+		if encoding == "" && acceptsEncoding(c.GetHeader("Accept-Encoding"), enc.name) {
+			servedName, encoding = name+enc.ext, enc.name
+		}
+	}
+
+	f, err := fsys.Open(servedName)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	servedInfo, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return false
+		}
+		content = bytes.NewReader(data)
+	}
+
+	header := c.Writer.Header()
+	if hasCompressed {
+		header.Add("Vary", "Accept-Encoding")
+	}
+	if encoding != "" {
+		header.Set("Content-Encoding", encoding)
+		// Content-Type 按原文件后缀推断，而非 .br/.gz
+		if ctype := mime.TypeByExtension(filepath.Ext(name)); ctype != "" {
+			header.Set("Content-Type", ctype)
+		}
+	}
+	header.Set("Cache-Control", cacheControlFor(name))
+	if etag, err := fileETag(mount, servedName, servedInfo, content); err == nil {
+		header.Set("ETag", etag)
+	}
+
+	// ETag 已设置时，ServeContent 会自动处理 If-None-Match 并返回 304；
+	// embed.FS 中文件的修改时间为零值，此时 ServeContent 不输出 Last-Modified
+	http.ServeContent(c.Writer, c.Request, path.Base(name), servedInfo.ModTime(), content)
+	return true
+}
+
+// precompressedEncodings 预压缩文件后缀，按优先级排列
+var precompressedEncodings = []struct {
+	name string
+	ext  string
+}{
+	{name: "br", ext: ".br"},
+	{name: "gzip", ext: ".gz"},
+}
+
+// acceptsEncoding 判断 Accept-Encoding 是否接受指定编码（q=0 视为不接受）
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		token, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(token), encoding) {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// hashedAssetPattern 匹配带内容哈希的文件名：name-4f8a1b2c.js、name.3f2a1b9c.css、index-BqX3_kD9.js
+var hashedAssetPattern = regexp.MustCompile(`[.-]([A-Za-z0-9_]{8,})\.[A-Za-z0-9]+$`)
+
+// isHashedAsset 判断是否为带内容哈希的文件（哈希段至少包含一位数字，避免误判 index-component.js）
+func isHashedAsset(name string) bool {
+	m := hashedAssetPattern.FindStringSubmatch(path.Base(name))
+	return len(m) == 2 && strings.ContainsAny(m[1], "0123456789")
+}
+
+// cacheControlFor 返回文件的 Cache-Control 策略
+func cacheControlFor(name string) string {
+	switch {
+	case strings.EqualFold(filepath.Ext(name), ".html"):
+		return "no-cache"
+	case isHashedAsset(name):
+		return "public, max-age=31536000, immutable"
+	}
+	if sec, err := strconv.Atoi(ci.C("frontend.max_age")); err == nil && sec > 0 {
+		return "public, max-age=" + strconv.Itoa(sec)
+	}
+	return "no-cache"
+}
+
+// etagCacheLimit ETag 缓存的最大条目数，超出时随机淘汰
+const etagCacheLimit = 4096
+
+// etagKey ETag 缓存键：挂载点、路径、大小与修改时间，文件变更后自动失效
+type etagKey struct {
+	mount   string
+	name    string
+	size    int64
+	modTime int64
+}
+
+var (
+	etagCache   = make(map[etagKey]string)
+	etagCacheMu sync.Mutex
+)
+
+// fileETag 计算文件的强 ETag（内容 SHA-1 前 16 位），读取后将 content 复位到开头
+func fileETag(mount, name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	key := etagKey{mount: mount, name: name, size: info.Size(), modTime: info.ModTime().UnixNano()}
+	etagCacheMu.Lock()
+	etag, ok := etagCache[key]
+	etagCacheMu.Unlock()
+	if ok {
+		return etag, nil
+	}
+	h := sha1.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag = `"` + hex.EncodeToString(h.Sum(nil))[:16] + `"`
+	etagCacheMu.Lock()
+	if len(etagCache) >= etagCacheLimit {
+		// map 遍历顺序随机，删除首个即随机淘汰
+		for k := range etagCache {
+			delete(etagCache, k)
+			break
+		}
+	}
+	etagCache[key] = etag
+	etagCacheMu.Unlock()
+	return etag, nil
+}
+
+// isStaticExt 判断文件后缀是否属于静态资源
+func isStaticExt(name string, exts []string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == "" {
+		return false
+	}
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFrontendMount 按最长前缀匹配子前端项目（不含根项目），mounts 需按前缀长度倒序
+func matchFrontendMount(mounts []ci.FrontendMount, urlPath string) (ci.FrontendMount, bool) {
+	for _, m := range mounts {
+		if m.Prefix == "/" {
+			continue
+		}
+		if urlPath == m.Prefix || strings.HasPrefix(urlPath, m.Prefix+"/") {
+			return m, true
+		}
+	}
+	return ci.FrontendMount{}, false
+}
+
+// rootFrontendMount 返回根项目挂载
+func rootFrontendMount(mounts []ci.FrontendMount) (ci.FrontendMount, bool) {
+	for _, m := range mounts {
+		if m.Prefix == "/" {
+			return m, true
+		}
+	}
+	return ci.FrontendMount{}, false
+}
+
+// serveRootFrontend 处理根项目的静态资源和 history 路由，返回 false 表示未处理
+func serveRootFrontend(c *gin.Context, mounts []ci.FrontendMount) bool {
+	root, ok := rootFrontendMount(mounts)
+	if !ok {
+		return false
+	}
+	urlPath := c.Request.URL.Path
+	if urlPath == "/" || urlPath == "" {
+		return serveFSFile(c, "frontend:"+root.Prefix, root.FS, root.Options.Index)
+	}
+	return serveFrontendFile(c, root, urlPath)
+}
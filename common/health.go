@@ -0,0 +1,57 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qinuoyun/caleyi/utils/ci"
+)
+
+// BindHealthRoutes 注册健康检查路由（不经过 JWT/租户中间件）：
+//
+//	GET /healthz  存活探针，进程可响应即返回 200
+//	GET /livez    同 /healthz
+//	GET /readyz   就绪探针，执行 ci.BinHealthCheck 注册的检查项（含内置 db），迁移中/停机中返回 503
+//
+// /readyz 无需鉴权，仅返回各检查项的名称与状态；失败原因（可能含数据库地址等）只写入日志。
+//
+// 配置：health.enabled=false 时不注册；health.timeout 为就绪检查超时（秒，默认 3）。
+func BindHealthRoutes(R *gin.Engine) {
+	if ci.C("health.enabled") == "false" {
+		return
+	}
+	live := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+	R.GET("/healthz", live)
+	R.GET("/livez", live)
+	R.GET("/readyz", func(c *gin.Context) {
+		timeout := 3 * time.Second
+		if sec, err := strconv.Atoi(ci.C("health.timeout")); err == nil && sec > 0 {
+			timeout = time.Duration(sec) * time.Second
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		report := ci.RunHealthChecks(ctx)
+		status := http.StatusOK
+		if report.Status != "ok" {
+			status = http.StatusServiceUnavailable
+			if report.Reason != "" {
+				fmt.Printf("[health] 未就绪: %s\n", report.Reason)
+				report.Reason = ""
+			}
+		}
+		for i, r := range report.Checks {
+			if r.Error != "" {
+				fmt.Printf("[health] 检查 %s 失败: %s\n", r.Name, r.Error)
+				report.Checks[i].Error = ""
+			}
+		}
+		c.JSON(status, report)
+	})
+}
@@ -0,0 +1,155 @@
+package common
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qinuoyun/caleyi/middleware"
+	"github.com/qinuoyun/caleyi/utils/ci"
+)
+
+// RouteManifestItem 路由清单项
+type RouteManifestItem struct {
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Handler     string   `json:"handler"`          // 控制器类型.方法名，非自动路由为 gin 处理函数名
+	Plugin      string   `json:"plugin,omitempty"` // 所属插件，应用自身路由为空
+	Group       string   `json:"group"`            // api / agent / ws / app / plugin / static / frontend / system / root
+	Middlewares []string `json:"middlewares"`      // 生效的中间件链（按执行顺序）
+	Whitelisted bool     `json:"whitelisted"`      // 是否命中 whitelist.items 或声明为 Public（跳过 JWT）
+}
+
+// apiChainNames 返回 /api 与 /agent 组中作用于指定路由的中间件链名称，与 registerAPIMiddlewareChain 保持一致；
+// plugin 为路由所属插件，path 为路由路径，用于按 Scope() 过滤
+func apiChainNames(middlewareList []interface{}, plugin, path string) []string {
+	var chain []string
+	add := func(stage string) {
+		for _, mw := range middlewareList {
+			if ok, _ := HasMethodByReflect(mw, stage); !ok {
+				continue
+			}
+			if scope, err := ci.GetMiddlewareScope(mw); err != nil || !scope.Applies(plugin, path) {
+				continue
+			}
+			chain = append(chain, ci.RemoveStarFromTypeName(mw)+"."+stage)
+		}
+	}
+	add("HandleBefore")
+	chain = append(chain, "JwtVerify", "TenantVerify")
+	add("HandleAfter")
+	// [middleware] api / agent 配置的具名中间件
+	group := "api"
+	if strings.HasPrefix(path, agentHTTPPathPrefix()+"/") {
+		group = "agent"
+	}
+	return append(chain, ci.GroupMiddlewareSpecs(group)...)
+}
+
+// BuildRouteManifest 根据引擎中的实际路由生成清单：
+// 自动路由使用注册时记录的归属信息（ci.SetRouteOwner），其余路由按路径前缀归类。
+func BuildRouteManifest(R *gin.Engine) []RouteManifestItem {
+	middlewareList := ci.GetMiddlewaresList()
+	agentPrefix := agentHTTPPathPrefix()
+	wsPrefix := ci.C("ws.prefix")
+	if wsPrefix == "" {
+		wsPrefix = "/ws"
+	}
+
+	items := make([]RouteManifestItem, 0)
+	for _, r := range R.Routes() {
+		item := RouteManifestItem{
+			Method:      r.Method,
+			Path:        r.Path,
+			Handler:     r.Handler,
+			Middlewares: []string{},
+		}
+		if owner, ok := ci.GetRouteOwner(r.Method, r.Path); ok {
+			item.Handler = owner.Handler
+			item.Plugin = owner.Plugin
+			item.Group = owner.Group
+			if owner.Middlewares != nil {
+				item.Middlewares = owner.Middlewares
+			}
+		} else {
+			switch {
+			case r.Path == "/healthz" || r.Path == "/livez" || r.Path == "/readyz":
+				item.Group = "system"
+			case strings.HasPrefix(r.Path, "/api/system/"):
+				item.Group = "system"
+				item.Middlewares = apiChainNames(middlewareList, "", r.Path)
+			case strings.HasPrefix(r.Path, "/api/"):
+				item.Group = "api"
+				item.Middlewares = apiChainNames(middlewareList, "", r.Path)
+			case strings.HasPrefix(r.Path, agentPrefix+"/"):
+				item.Group = "agent"
+				item.Middlewares = apiChainNames(middlewareList, "", r.Path)
+			case strings.HasPrefix(r.Path, wsPrefix+"/") || r.Path == wsPrefix:
+				item.Group = "ws"
+				item.Middlewares = append([]string{"WsVerify"}, ci.GroupMiddlewareSpecs("ws")...)
+			case strings.HasSuffix(r.Path, "/*filepath"):
+				item.Group = "static"
+			case strings.HasSuffix(r.Path, "/*any"):
+				item.Group = "frontend"
+			default:
+				item.Group = "root"
+			}
+		}
+		if hasJwt(item.Middlewares) {
+			item.Whitelisted = middleware.IsWhitelisted(r.Path) || ci.IsPublicRoute(r.Method, r.Path)
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Path == items[j].Path {
+			return items[i].Method < items[j].Method
+		}
+		return items[i].Path < items[j].Path
+	})
+	return items
+}
+
+// hasJwt 判断中间件链中是否包含 JwtVerify
+func hasJwt(chain []string) bool {
+	for _, name := range chain {
+		if name == "JwtVerify" {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteRouteManifest 将路由清单以 JSON 写入文件
+func WriteRouteManifest(R *gin.Engine, filePath string) error {
+	data, err := json.MarshalIndent(BuildRouteManifest(R), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ci.WriteToFile(filePath, string(data))
+}
+
+// bindSystemRoutes 在 /api 组下注册系统路由（继承 JWT/Tenant 验证），仅 CrossTenant 账号（平台管理员）可访问：
+//
+//	GET /api/system/routes    路由清单
+//	GET /api/system/services  服务注册表
+//	/api/system/tenants       租户管理（tenant.registry=true 时，见 bindTenantRoutes）
+func bindSystemRoutes(R *gin.Engine, apiGroup *gin.RouterGroup) {
+	systemG := apiGroup.Group("/system", requireCrossTenant)
+	systemG.GET("/routes", func(c *gin.Context) {
+		ci.Success(c, BuildRouteManifest(R))
+	})
+	systemG.GET("/services", func(c *gin.Context) {
+		ci.Success(c, ci.ServiceReport())
+	})
+	bindTenantRoutes(systemG)
+}
+
+// requireCrossTenant 仅允许 CrossTenant 账号（平台管理员）访问系统路由
+func requireCrossTenant(c *gin.Context) {
+	if claims, ok := middleware.GetUserClaims(c); !ok || !claims.CrossTenant {
+		c.AbortWithStatusJSON(403, gin.H{"code": 403, "msg": "仅平台管理员可访问系统接口"})
+		return
+	}
+	c.Next()
+}
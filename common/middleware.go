@@ -1,15 +1,66 @@
 package common
 
-func InitMiddleware() {
+import (
+	"errors"
+	"fmt"
+	"strings"
 
-	// 获取所有模块
-	//serverMap := ci.GetServers()
+	"github.com/gin-gonic/gin"
+	"github.com/qinuoyun/caleyi/utils/ci"
+)
 
-	//fmt.Printf("看看有多少内容%T\n", serverMap)
+// middlewareGroups [middleware] 配置支持的分组
+var middlewareGroups = []string{"global", "api", "agent", "ws", "plugin"}
 
-	// 循环遍历并打印
-	//for _, value := range serverMap {
-	//	//fmt.Printf("对应的服务模块: %T\n", value)
-	//}
+// tenantResolverGroups [tenant_resolver] 配置支持的分组
+var tenantResolverGroups = []string{"api", "agent", "ws"}
 
+// InitMiddleware 校验 [middleware] 配置引用的具名中间件（见 ci.RegisterMiddleware）均已注册且参数有效、
+// [tenant_resolver] 引用的租户解析器均已注册，
+// 在构建路由之前调用，配置有误时拒绝启动：
+//
+//	global  所有路由（含静态资源与前端）
+//	api     /api 组，在 JWT/租户校验之后执行
+//	agent   /agent 组，在 JWT/租户校验之后执行
+//	ws      /ws 组，在 WsVerify 之后执行
+//	plugin  插件自动路由，在 action 之前执行
+func InitMiddleware() error {
+	var errs []error
+	for _, group := range middlewareGroups {
+		specs := ci.GroupMiddlewareSpecs(group)
+		if len(specs) == 0 {
+			continue
+		}
+		if _, _, err := ci.BuildMiddlewares(specs); err != nil {
+			errs = append(errs, fmt.Errorf("middleware.%s: %w", group, err))
+			continue
+		}
+		fmt.Printf("[middleware] %s: %s\n", group, strings.Join(specs, ", "))
+	}
+	// [tenant_resolver] 引用的租户解析器（见 ci.BinTenantResolver）
+	for _, group := range tenantResolverGroups {
+		if err := ci.CheckTenantResolvers(group); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		fmt.Printf("[tenant] %s 租户解析顺序: %s\n", group, strings.Join(ci.TenantResolverSpecs(group), " → "))
+	}
+	return errors.Join(errs...)
+}
+
+// groupMiddlewares 创建 [middleware] 配置中指定分组的中间件，出错时记录到 ci.RouteError
+func groupMiddlewares(group string) ([]gin.HandlerFunc, []string) {
+	handlers, names, err := ci.BuildMiddlewares(ci.GroupMiddlewareSpecs(group))
+	if err != nil {
+		ci.AddRouteError(fmt.Errorf("middleware.%s: %w", group, err))
+		return nil, nil
+	}
+	return handlers, names
+}
+
+// useGroupMiddlewares 在路由组上注册 [middleware] 配置中指定分组的中间件
+func useGroupMiddlewares(g gin.IRoutes, group string) {
+	if handlers, _ := groupMiddlewares(group); len(handlers) > 0 {
+		g.Use(handlers...)
+	}
 }
@@ -16,12 +16,68 @@ import (
 	"gorm.io/gorm/schema"
 )
 
+// InitModule 按配置连接数据库并执行迁移，失败时直接退出进程（BootStart 使用）
 func InitModule() {
+	db, err := OpenDB()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if err := Migrate(db); err != nil {
+		log.Fatalf("%v", err)
+	}
+	// 将 DB 实例设置到 ci 包中
+	ci.SetDB(db)
+}
+
+// OpenDB 根据 app.app_sql 及对应数据库配置建立 GORM 连接；tenant.mode 为 schema / database 时同时设置租户存储（见 UseTenantStores）
+func OpenDB() (*gorm.DB, error) {
+	if err := ci.CheckTenantMode(); err != nil {
+		return nil, err
+	}
 	sqlType := ci.C("app.app_sql")
+	dialector, database, err := dialectorFor(sqlType, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	// 连接数据库（使用统一的 dialector 接口）
+	_DB, err := gorm.Open(dialector, GormConfig())
+	if err != nil {
+		return nil, fmt.Errorf("数据库连接失败：%w", err)
+	}
+
+	sqlDB, err := _DB.DB()
+	if err != nil {
+		return nil, fmt.Errorf("数据库连接失败：%w", err)
+	}
+
+	// 打开 Debug 日志
+	_DB.Debug()
+
+	// 租户隔离：嵌入 ci.Model 的模型自动追加 tenant_id 条件（tenant.auth=false 时不启用）；以下失败时关闭已打开的连接
+	if err := ci.UseTenantPlugin(_DB); err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+
+	fmt.Println("===========================")
+	fmt.Printf("数据库连接成功！类型：%s，数据库名：%s\n", sqlType, database)
+	fmt.Println("===========================")
+
+	if err := UseTenantStores(_DB); err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+	return _DB, nil
+}
+
+// dialectorFor 按数据库类型与配置构建驱动，返回驱动与数据库名；
+// database 非空时替换配置中的库名（SQLite 为文件路径），schema 非空时设置 PostgreSQL 的 search_path
+func dialectorFor(sqlType, database, schema string) (gorm.Dialector, string, error) {
 	var (
 		// 声明变量，作用域覆盖整个函数
-		ip, port, user, password, database string
-		dialector                          gorm.Dialector // 统一驱动接口
+		ip, port, user, password string
+		dialector                gorm.Dialector // 统一驱动接口
 	)
 
 	// 根据 sqlType 读取对应配置并选择驱动
@@ -32,7 +88,9 @@ func InitModule() {
 		port = ci.C("mysql.port")
 		user = ci.C("mysql.user")
 		password = ci.C("mysql.password")
-		database = ci.C("mysql.database")
+		if database == "" {
+			database = ci.C("mysql.database")
+		}
 		// MySQL DSN 格式
 		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 			user, password, ip, port, database)
@@ -44,31 +102,47 @@ func InitModule() {
 		port = ci.C("pgsql.port")
 		user = ci.C("pgsql.user")
 		password = ci.C("pgsql.password")
-		database = ci.C("pgsql.database")
+		if database == "" {
+			database = ci.C("pgsql.database")
+		}
 		// PostgreSQL DSN 格式（注意字段名和 MySQL 不同）
 		dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable TimeZone=Asia/Shanghai",
 			ip, port, user, password, database)
+		if schema != "" {
+			// 连接级 search_path：该连接池上的表均位于租户 schema
+			dsn += " search_path=" + schema
+		}
 		dialector = postgres.Open(dsn) // PostgreSQL 驱动
 
 	case "sqlite":
 		// 读取 SQLite 配置，file 为数据库文件路径；文件不存在时 GORM 会自动创建
-		dbFile := ci.C("sqlite.file")
-		if dbFile == "" {
-			dbFile = "./runtime/data.db"
+		if database == "" {
+			database = sqliteFile()
 		}
 		// 自动创建所在目录，否则 Open 可能失败
-		if dir := filepath.Dir(dbFile); dir != "." {
+		if dir := filepath.Dir(database); dir != "." {
 			if err := os.MkdirAll(dir, 0755); err != nil {
-				log.Fatalf("创建 SQLite 数据目录失败: %v", err)
+				return nil, "", fmt.Errorf("创建 SQLite 数据目录失败: %w", err)
 			}
 		}
-		dialector = sqlite.Open(dbFile)
-		database = dbFile
+		dialector = sqlite.Open(database)
 
 	default:
-		log.Fatalf("不支持的数据库类型：%s（仅支持 mysql/postgre/sqlite）", sqlType)
+		return nil, "", fmt.Errorf("不支持的数据库类型：%s（仅支持 mysql/postgre/sqlite）", sqlType)
 	}
+	return dialector, database, nil
+}
 
+// sqliteFile 返回 sqlite.file，未配置时为 ./runtime/data.db
+func sqliteFile() string {
+	if dbFile := ci.C("sqlite.file"); dbFile != "" {
+		return dbFile
+	}
+	return "./runtime/data.db"
+}
+
+// GormConfig 返回框架统一的 GORM 配置（表前缀、单数表名、日志级别），外部自建连接时可复用
+func GormConfig() *gorm.Config {
 	// 初始化 GORM 日志配置
 	newLogger := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags),
@@ -80,44 +154,46 @@ func InitModule() {
 	)
 
 	// GORM 全局配置
-	options := &gorm.Config{
+	return &gorm.Config{
 		NamingStrategy: schema.NamingStrategy{
 			TablePrefix:   "pre_", // 表前缀
 			SingularTable: true,   // 禁用表名复数
 		},
 		Logger: newLogger,
 	}
+}
 
-	// 连接数据库（使用统一的 dialector 接口）
-	_DB, err := gorm.Open(dialector, options)
-	if err != nil {
-		log.Fatalf("数据库连接失败：%v", err)
+// Migrate 迁移所有已注册的模块与插件模板；tenant.mode 为 schema / database 时迁移到每个租户存储（见 MigrateTenantStores）
+func Migrate(db *gorm.DB) error {
+	// 租户注册表（tenant.registry=true 时），始终位于共享库
+	if ci.TenantRegistryEnabled() {
+		if err := ci.MigrateTenants(db); err != nil {
+			return err
+		}
 	}
+	if ci.TenantMode() != ci.TenantModeColumn {
+		return MigrateTenantStores(db)
+	}
+	return migrateModules(db)
+}
 
-	// 打开 Debug 日志
-	_DB.Debug()
-
+// migrateModules 迁移模块与插件模板到指定连接
+func migrateModules(db *gorm.DB) error {
 	// 迁移模块（原逻辑保留）
 	moduleMap := ci.GetModules()
 	for _, value := range moduleMap {
-		if err := _DB.AutoMigrate(value); err != nil {
-			log.Fatalf("模块迁移失败：%v", err)
+		if err := db.AutoMigrate(value); err != nil {
+			return fmt.Errorf("模块迁移失败：%w", err)
 		}
 	}
 
 	// 迁移插件模板（原逻辑保留）
 	for _, modules := range ModulesPool {
 		for _, module := range modules {
-			if err := _DB.AutoMigrate(module); err != nil {
-				log.Fatalf("插件模板迁移失败：%v", err)
+			if err := db.AutoMigrate(module); err != nil {
+				return fmt.Errorf("插件模板迁移失败：%w", err)
 			}
 		}
 	}
-
-	fmt.Println("===========================")
-	fmt.Printf("数据库连接成功！类型：%s，数据库名：%s\n", sqlType, database)
-	fmt.Println("===========================")
-
-	// 将 DB 实例设置到 ci 包中
-	ci.SetDB(_DB)
+	return nil
 }
@@ -0,0 +1,469 @@
+package common
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qinuoyun/caleyi/middleware"
+	"github.com/qinuoyun/caleyi/utils/ci"
+)
+
+// OpenAPI 文档结构（仅包含框架生成所需的 OpenAPI 3.0 子集）
+type (
+	OpenAPIDoc struct {
+		OpenAPI    string                                  `json:"openapi"`
+		Info       OpenAPIInfo                             `json:"info"`
+		Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+		Components OpenAPIComponents                       `json:"components"`
+	}
+	OpenAPIInfo struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	}
+	OpenAPIComponents struct {
+		Schemas         map[string]*OpenAPISchema         `json:"schemas"`
+		SecuritySchemes map[string]map[string]interface{} `json:"securitySchemes"`
+	}
+	OpenAPIOperation struct {
+		OperationID string                     `json:"operationId"`
+		Summary     string                     `json:"summary,omitempty"`
+		Tags        []string                   `json:"tags,omitempty"`
+		Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+		RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+		Responses   map[string]OpenAPIResponse `json:"responses"`
+		Security    []map[string][]string      `json:"security,omitempty"`
+	}
+	OpenAPIParameter struct {
+		Name     string         `json:"name"`
+		In       string         `json:"in"` // path / query / header
+		Required bool           `json:"required,omitempty"`
+		Schema   *OpenAPISchema `json:"schema"`
+	}
+	OpenAPIRequestBody struct {
+		Required bool                        `json:"required,omitempty"`
+		Content  map[string]OpenAPIMediaType `json:"content"`
+	}
+	OpenAPIResponse struct {
+		Description string                      `json:"description"`
+		Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+	}
+	OpenAPIMediaType struct {
+		Schema *OpenAPISchema `json:"schema"`
+	}
+	OpenAPISchema struct {
+		Ref                  string                    `json:"$ref,omitempty"`
+		Type                 string                    `json:"type,omitempty"`
+		Format               string                    `json:"format,omitempty"`
+		Items                *OpenAPISchema            `json:"items,omitempty"`
+		Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+		AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+		Required             []string                  `json:"required,omitempty"`
+		Nullable             bool                      `json:"nullable,omitempty"`
+	}
+)
+
+// openAPIGroups 参与生成文档的路由分组（静态资源、前端、WebSocket 等不输出）
+var openAPIGroups = map[string]bool{"api": true, "app": true, "plugin": true, "agent": true, "system": true}
+
+// pathParamPattern 匹配 gin 路径参数 :id
+var pathParamPattern = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// BuildOpenAPI 根据路由清单生成 OpenAPI 3 文档：
+//   - 路径、方法、所属插件/分组来自 BuildRouteManifest
+//   - 中间件链含 JwtVerify 且未加入白名单时要求 Bearer 鉴权，含 TenantVerify 时声明 tenant_id 请求头
+//   - typed action（见 ci.BuildHandler）按请求结构体生成查询参数或请求体，按返回值类型生成响应 data
+func BuildOpenAPI(R *gin.Engine) *OpenAPIDoc {
+	title := ci.C("docs.title")
+	if title == "" {
+		title = ci.C("app.app_name") + " API"
+	}
+	version := ci.C("docs.version")
+	if version == "" {
+		version = "1.0.0"
+	}
+	doc := &OpenAPIDoc{
+		OpenAPI: "3.0.3",
+		Info:    OpenAPIInfo{Title: title, Version: version},
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+		Components: OpenAPIComponents{
+			Schemas: make(map[string]*OpenAPISchema),
+			SecuritySchemes: map[string]map[string]interface{}{
+				"bearerAuth": {"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+	gen := &schemaGenerator{schemas: doc.Components.Schemas, names: make(map[reflect.Type]string)}
+	docsPrefix := docsPath()
+
+	for _, item := range BuildRouteManifest(R) {
+		if !openAPIGroups[item.Group] || item.Method == http.MethodHead || strings.Contains(item.Path, "*") {
+			continue
+		}
+		if docsPrefix != "" && strings.HasPrefix(item.Path, docsPrefix) {
+			continue
+		}
+		op := &OpenAPIOperation{
+			OperationID: item.Method + " " + item.Path,
+			Summary:     item.Handler,
+			Tags:        []string{openAPITag(item)},
+			Responses:   make(map[string]OpenAPIResponse),
+		}
+		for _, m := range pathParamPattern.FindAllStringSubmatch(item.Path, -1) {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{Name: m[1], In: "path", Required: true, Schema: &OpenAPISchema{Type: "string"}})
+		}
+		if containsName(item.Middlewares, "TenantVerify") {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{Name: "tenant_id", In: "header", Schema: &OpenAPISchema{Type: "string"}})
+		}
+		if hasJwt(item.Middlewares) && !item.Whitelisted {
+			op.Security = []map[string][]string{{"bearerAuth": {}}}
+			op.Responses["401"] = OpenAPIResponse{Description: "未登录或 token 无效"}
+		}
+
+		var dataSchema *OpenAPISchema
+		if owner, ok := ci.GetRouteOwner(item.Method, item.Path); ok {
+			if owner.Request != nil {
+				gen.applyRequest(op, item.Method, owner.Request)
+			}
+			if owner.Response != nil {
+				dataSchema = gen.schemaOf(owner.Response)
+			}
+		}
+		op.Responses["200"] = OpenAPIResponse{
+			Description: "统一响应，code=0 表示成功",
+			Content:     map[string]OpenAPIMediaType{"application/json": {Schema: envelopeSchema(dataSchema)}},
+		}
+
+		oaPath := pathParamPattern.ReplaceAllString(item.Path, "{$1}")
+		if doc.Paths[oaPath] == nil {
+			doc.Paths[oaPath] = make(map[string]*OpenAPIOperation)
+		}
+		doc.Paths[oaPath][strings.ToLower(item.Method)] = op
+	}
+	return doc
+}
+
+// openAPITag 按插件或路径归类接口
+func openAPITag(item RouteManifestItem) string {
+	if item.Plugin != "" {
+		return item.Plugin
+	}
+	segments := strings.Split(strings.Trim(item.Path, "/"), "/")
+	if len(segments) >= 2 && segments[0] == "api" {
+		return segments[1]
+	}
+	return item.Group
+}
+
+// envelopeSchema 统一响应结构 ci.APIResponse
+func envelopeSchema(data *OpenAPISchema) *OpenAPISchema {
+	if data == nil {
+		data = &OpenAPISchema{Type: "object", Nullable: true}
+	}
+	return &OpenAPISchema{
+		Type: "object",
+		Properties: map[string]*OpenAPISchema{
+			"code": {Type: "integer"},
+			"msg":  {Type: "string"},
+			"data": data,
+		},
+	}
+}
+
+func containsName(list []string, name string) bool {
+	for _, n := range list {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaGenerator 通过反射生成结构体 schema，命名结构体放入 components.schemas 并以 $ref 引用（支持递归类型）
+type schemaGenerator struct {
+	schemas map[string]*OpenAPISchema
+	names   map[reflect.Type]string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// applyRequest 将请求结构体写入操作：GET/DELETE 生成查询参数，其它方法生成 JSON 请求体；uri 字段已由路径参数覆盖
+func (g *schemaGenerator) applyRequest(op *OpenAPIOperation, method string, t reflect.Type) {
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	if method == http.MethodGet || method == http.MethodDelete {
+		for _, f := range requestFields(t) {
+			schema := g.basicOrTime(f.typ)
+			// 嵌套结构体、map 无法通过查询串绑定
+			if f.uri || schema == nil {
+				continue
+			}
+			op.Parameters = append(op.Parameters, OpenAPIParameter{Name: f.form, In: "query", Required: f.required, Schema: schema})
+		}
+		return
+	}
+	op.RequestBody = &OpenAPIRequestBody{
+		Required: true,
+		Content:  map[string]OpenAPIMediaType{"application/json": {Schema: g.schemaOf(t)}},
+	}
+}
+
+// basicOrTime 返回可用于查询参数的 schema（标量、时间及其切片），其它类型返回 nil
+func (g *schemaGenerator) basicOrTime(t reflect.Type) *OpenAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	elem := t
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		elem = t.Elem()
+	}
+	if elem != timeType && (elem.Kind() == reflect.Struct || elem.Kind() == reflect.Map || elem.Kind() == reflect.Interface) {
+		return nil
+	}
+	return g.schemaOf(t)
+}
+
+// requestField 请求结构体字段
+type requestField struct {
+	json, form string
+	typ        reflect.Type
+	required   bool
+	uri        bool
+}
+
+// requestFields 展开结构体字段（含匿名嵌入），忽略未导出字段与 json:"-"
+func requestFields(t reflect.Type) []requestField {
+	var fields []requestField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+		ft := f.Type
+		if f.Anonymous && jsonName == "" {
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != timeType {
+				fields = append(fields, requestFields(ft)...)
+				continue
+			}
+		}
+		if jsonName == "" {
+			jsonName = f.Name
+		}
+		formName, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if formName == "" || formName == "-" {
+			formName = jsonName
+		}
+		fields = append(fields, requestField{
+			json:     jsonName,
+			form:     formName,
+			typ:      f.Type,
+			required: strings.Contains(","+f.Tag.Get("binding")+",", ",required,"),
+			uri:      f.Tag.Get("uri") != "",
+		})
+	}
+	return fields
+}
+
+// schemaOf 返回类型对应的 schema
+func (g *schemaGenerator) schemaOf(t reflect.Type) *OpenAPISchema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+	var s *OpenAPISchema
+	switch {
+	case t == timeType:
+		s = &OpenAPISchema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct:
+		return g.structRef(t)
+	default:
+		s = g.basicSchema(t)
+	}
+	s.Nullable = s.Nullable || nullable
+	return s
+}
+
+func (g *schemaGenerator) basicSchema(t reflect.Type) *OpenAPISchema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	default:
+		// interface{}、gin.H 中的值等无法推断的类型
+		return &OpenAPISchema{}
+	}
+}
+
+// structRef 生成结构体 schema 并返回 $ref；匿名结构体直接内联
+func (g *schemaGenerator) structRef(t reflect.Type) *OpenAPISchema {
+	if t.Name() == "" {
+		return g.structSchema(t)
+	}
+	if name, ok := g.names[t]; ok {
+		return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+	}
+	name := schemaName(t)
+	// 不同包的同名类型追加序号
+	for i := 2; g.schemas[name] != nil; i++ {
+		name = fmt.Sprintf("%s%d", schemaName(t), i)
+	}
+	g.names[t] = name
+	g.schemas[name] = &OpenAPISchema{} // 占位，支持递归引用
+	*g.schemas[name] = *g.structSchema(t)
+	return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *OpenAPISchema {
+	s := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+	for _, f := range requestFields(t) {
+		s.Properties[f.json] = g.schemaOf(f.typ)
+		if f.required {
+			s.Required = append(s.Required, f.json)
+		}
+	}
+	return s
+}
+
+// schemaName 结构体在 components 中的名称：包名.类型名 → 包名_类型名
+func schemaName(t reflect.Type) string {
+	name := t.String()
+	name = strings.NewReplacer(".", "_", "[", "_", "]", "", "*", "", " ", "").Replace(name)
+	return name
+}
+
+// docsPath 返回文档路由前缀，未开启时为空：docs.enabled=true 时生效，docs.path 默认 /docs
+func docsPath() string {
+	if ci.C("docs.enabled") != "true" {
+		return ""
+	}
+	p := strings.TrimSpace(ci.C("docs.path"))
+	if p == "" {
+		p = "/docs"
+	}
+	return "/" + strings.Trim(p, "/")
+}
+
+// BindDocsRoutes 注册接口文档路由（docs.enabled=true 时）：
+//
+//	GET {docs.path}                  文档页面：默认为内置查看器，配置 docs.assets 时为 Swagger UI
+//	GET {docs.path}/openapi.json     OpenAPI 3 文档，经 JwtVerify 校验且仅 CrossTenant 账号可访问（同 /api/system）
+//	GET {docs.path}/assets/*filepath Swagger UI 静态资源（配置 docs.assets 时）
+//
+// 页面与静态资源不含接口信息，无需登录即可打开，页面内填写 token 后再加载文档；
+// 文档在首次请求时生成并缓存（路由在启动后不再变化）。
+func BindDocsRoutes(R *gin.Engine) {
+	prefix := docsPath()
+	if prefix == "" {
+		return
+	}
+	var (
+		once sync.Once
+		spec *OpenAPIDoc
+	)
+	R.GET(prefix+"/openapi.json", middleware.JwtVerify, requireCrossTenant, func(c *gin.Context) {
+		once.Do(func() { spec = BuildOpenAPI(R) })
+		c.JSON(http.StatusOK, spec)
+	})
+	assets := ci.DocsAssetsFS()
+	page := docsViewerHTML(prefix)
+	if assets != nil {
+		page = swaggerUIHTML(prefix)
+		R.GET(prefix+"/assets/*filepath", func(c *gin.Context) {
+			if !serveFSFile(c, "docs:"+prefix, assets, strings.TrimPrefix(c.Param("filepath"), "/")) {
+				c.Status(http.StatusNotFound)
+			}
+		})
+	}
+	R.GET(prefix, func(c *gin.Context) {
+		c.Header("Cache-Control", "no-cache")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	})
+	fmt.Printf("[docs] 接口文档: %s\n", prefix)
+}
+
+// docsViewerPage 内置文档查看器：无外部依赖，读取 openapi.json 渲染接口列表、参数与结构
+//
+//go:embed docs_viewer.html
+var docsViewerPage string
+
+// docsViewerHTML 生成内置查看器页面
+func docsViewerHTML(prefix string) []byte {
+	return []byte(strings.Replace(docsViewerPage, `"{{SPEC_URL}}"`, jsString(prefix+"/openapi.json"), 1))
+}
+
+// swaggerUIHTML 生成从 {docs.path}/assets 加载本地 swagger-ui-dist 的页面
+func swaggerUIHTML(prefix string) []byte {
+	page := strings.NewReplacer(
+		"{{BASE}}", html.EscapeString(prefix+"/assets"),
+		`"{{SPEC_URL}}"`, jsString(prefix+"/openapi.json"),
+	).Replace(swaggerUIPage)
+	return []byte(page)
+}
+
+// jsString 将字符串编码为可嵌入 <script> 的 JS 字符串字面量
+func jsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// swaggerUIPage Swagger UI 页面模板：token 与内置查看器共用 sessionStorage，获取文档时附加 Authorization
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+  <meta charset="utf-8">
+  <title>API 文档</title>
+  <link rel="stylesheet" href="{{BASE}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="{{BASE}}/swagger-ui-bundle.js"></script>
+  <script>
+    var specURL = "{{SPEC_URL}}";
+    var token = sessionStorage.getItem("caleyi_docs_token") || prompt("平台管理员 token") || "";
+    sessionStorage.setItem("caleyi_docs_token", token);
+    window.ui = SwaggerUIBundle({
+      url: specURL,
+      dom_id: "#swagger-ui",
+      persistAuthorization: true,
+      requestInterceptor: function (req) {
+        if (req.url.indexOf(specURL) >= 0 && token) req.headers.Authorization = "Bearer " + token;
+        return req;
+      }
+    });
+  </script>
+</body>
+</html>
+`
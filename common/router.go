@@ -1,9 +1,8 @@
 package common
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 	"reflect"
 	"strings"
 	"time"
@@ -142,6 +141,8 @@ func RegisterMiddlewareHandlers(R gin.IRoutes, middlewareList []interface{}, sta
 
 		// 捕获当前循环的 methodVal，解决闭包作用域覆盖问题
 		validMethod := methodVal
+		// 作用范围在 NewRouter 中已校验，非 global 时按路由归属在运行时判断
+		scope, _ := ci.GetMiddlewareScope(mw)
 		//fmt.Printf("  该中间件存在 %s 方法，开始注册\n", methodName)
 
 		// 封装为 Gin 中间件并注册
@@ -155,6 +156,9 @@ func RegisterMiddlewareHandlers(R gin.IRoutes, middlewareList []interface{}, sta
 				//fmt.Printf("  警告：gin.Context 为 nil，跳过执行\n")
 				return
 			}
+			if !scope.Global() && !middlewareApplies(c, scope) {
+				return
+			}
 
 			// 准备参数并安全调用
 			params := []reflect.Value{reflect.ValueOf(c)}
@@ -169,31 +173,53 @@ func RegisterMiddlewareHandlers(R gin.IRoutes, middlewareList []interface{}, sta
 	//fmt.Printf("\n========== %s 中间件注册完成 ==========\n\n", methodName)
 }
 
-// 定义多前端项目的路径映射（统一管理，方便维护）
-var frontEndProjects = map[string]string{
-	"/admin":    "./views/admin",    // /admin -> 后台管理项目
-	"/h5":       "./views/h5",       // /h5 -> H5移动端项目
-	"/merchant": "./views/merchant", // /merchant -> 商户端项目
-	"/store":    "./views/store",    // /store -> 门店端项目
+// middlewareApplies 判断当前请求的路由是否在中间件作用范围内
+func middlewareApplies(c *gin.Context, scope ci.MiddlewareScope) bool {
+	path := c.FullPath()
+	if path == "" {
+		path = c.Request.URL.Path
+	}
+	owner, _ := ci.GetRouteOwner(c.Request.Method, path)
+	return scope.Applies(owner.Plugin, path)
 }
 
-// 静态资源后缀列表（用于过滤，避免静态资源被history路由拦截）
-var staticExts = []string{".js", ".css", ".png", ".jpg", ".jpeg", ".gif", ".ico", ".svg", ".woff", ".woff2", ".ttf", ".map", ".json", ".txt"}
+// validateMiddlewares 校验所有中间件声明的作用范围
+func validateMiddlewares(middlewareList []interface{}) error {
+	var errs []error
+	for _, mw := range middlewareList {
+		if _, err := ci.GetMiddlewareScope(mw); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
 
-// registerAPIMiddlewareChain 与 /api 相同：HandleBefore → 默认 tenant → JWT → TenantVerify → HandleAfter
-func registerAPIMiddlewareChain(g *gin.RouterGroup, middlewareList []interface{}) {
-	RegisterMiddlewareHandlers(g, middlewareList, "before")
-	g.Use(func(c *gin.Context) {
-		if c.GetHeader("tenant_id") == "" && c.Query("tenant_id") == "" {
-			if defaultTenantID := ci.C("app.tenant_id"); defaultTenantID != "" {
-				c.Request.Header.Set("tenant_id", defaultTenantID)
+// describeMiddlewareChain 输出路由组生效的中间件链，如 Auth.HandleBefore(priority=-10,scope=global) → JwtVerify → ...
+func describeMiddlewareChain(middlewareList []interface{}) string {
+	var parts []string
+	describe := func(stage string) {
+		for _, mw := range middlewareList {
+			if ok, _ := HasMethodByReflect(mw, stage); !ok {
+				continue
 			}
+			scope, _ := ci.GetMiddlewareScope(mw)
+			parts = append(parts, fmt.Sprintf("%s.%s(priority=%d,scope=%s)", ci.RemoveStarFromTypeName(mw), stage, ci.GetMiddlewarePriority(mw), scope))
 		}
-		c.Next()
-	})
+	}
+	describe("HandleBefore")
+	parts = append(parts, "JwtVerify", "TenantVerify")
+	describe("HandleAfter")
+	return strings.Join(parts, " → ")
+}
+
+// registerAPIMiddlewareChain 与 /api 相同：HandleBefore → JWT → TenantVerify → HandleAfter；
+// group 为 api 或 agent，TenantVerify 按 tenant_resolver.<group> 解析 tenant_id（默认租户 app.tenant_id 由 default 解析器兜底）
+func registerAPIMiddlewareChain(g *gin.RouterGroup, middlewareList []interface{}, group string) {
+	RegisterMiddlewareHandlers(g, middlewareList, "before")
 	g.Use(middleware.JwtVerify)
-	g.Use(middleware.TenantVerify)
+	g.Use(middleware.TenantVerifyGroup(group))
 	RegisterMiddlewareHandlers(g, middlewareList, "after")
+	fmt.Printf("[middleware] %s 中间件链: %s\n", g.BasePath(), describeMiddlewareChain(middlewareList))
 }
 
 // bindAgentHTTPRoutes 将业务通过 ci.BinAgentRoutes 注册的回调挂到根路径下的 agent 前缀（默认 /agent，非 /api 下）。
@@ -213,7 +239,8 @@ func bindAgentHTTPRoutes(R *gin.Engine, middlewareList []interface{}) {
 	}
 	prefix = strings.Trim(prefix, "/")
 	agentG := R.Group("/" + prefix)
-	registerAPIMiddlewareChain(agentG, middlewareList)
+	registerAPIMiddlewareChain(agentG, middlewareList, "agent")
+	useGroupMiddlewares(agentG, "agent")
 	for _, fn := range handlers {
 		fn(agentG)
 	}
@@ -233,71 +260,37 @@ func agentHTTPPathPrefix() string {
 	return "/" + p
 }
 
+// InitRouter 创建并返回路由实例，出错时返回 nil（兼容旧调用方式）
 func InitRouter() *gin.Engine {
+	R, err := NewRouter()
+	if err != nil {
+		fmt.Printf("[router] 初始化路由失败: %v\n", err)
+		return nil
+	}
+	return R
+}
+
+// NewRouter 创建路由实例并注册全部路由，每次调用返回独立的 gin.Engine
+func NewRouter() (*gin.Engine, error) {
 	//初始化路由
 	R := gin.Default()
 	err := R.SetTrustedProxies([]string{"127.0.0.1"})
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
-	// ========== 原有静态资源配置（保留基础路径，子项目由下文history逻辑处理） ==========
-	R.Static("/static", "./static")
-	R.Static("/public", "./public")
-	R.Static("/uploads", "./uploads")
-
-	// ========== 重构：统一处理多项目history路由（替代原有零散的/admin、/merchant处理逻辑） ==========
-	// 处理所有前端项目的GET请求，解决history刷新问题
-	for prefix, distDir := range frontEndProjects {
-		// 为每个项目前缀注册路由
-		R.GET(prefix+"/*any", func(c *gin.Context) {
-			// 获取通配符参数
-			filePath := c.Param("any")
-			// 确定目标项目目录
-			targetDir := distDir
-
-			// 1. 处理根路径特殊情况（/ 对应的any参数为空时）
-			if prefix == "/" && (filePath == "" || filePath == "/") {
-				fullPath := fmt.Sprintf("%s/index.html", targetDir)
-				if _, err := os.Stat(fullPath); err == nil {
-					c.File(fullPath)
-				} else {
-					// 保留原有根路径返回JSON的逻辑
-					c.JSON(200, gin.H{"code": 200, "message": "欢迎使用卡莱易框架"})
-				}
-				return
-			}
+	// ========== 全局具名中间件：[middleware] global，需在注册任何路由之前挂载 ==========
+	useGroupMiddlewares(R, "global")
 
-			// 2. 处理非根路径的情况（admin/h5/merchant/store）
-			if filePath == "" || strings.HasSuffix(filePath, "/") {
-				filePath = "index.html"
-			}
-			// 拼接完整文件路径（去除any参数开头的/，避免路径重复）
-			fullPath := fmt.Sprintf("%s/%s", targetDir, strings.TrimPrefix(filePath, "/"))
-
-			// 3. 判断是否为静态资源
-			ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(fullPath), "."))
-			isStatic := false
-			for _, e := range staticExts {
-				if ext == strings.TrimPrefix(e, ".") {
-					isStatic = true
-					break
-				}
-			}
+	// ========== 静态资源：由 [static] 配置与 ci.BinStatic 声明，支持磁盘目录与 embed.FS ==========
+	bindStaticRoutes(R, ci.GetStaticsList())
 
-			// 4. 静态资源存在则返回，不存在则404；非静态资源则返回index.html（history路由）
-			if isStatic {
-				if _, err := os.Stat(fullPath); err == nil {
-					c.File(fullPath)
-				} else {
-					c.Status(404)
-				}
-			} else {
-				// 非静态资源请求，返回项目的index.html（解决history刷新）
-				c.File(fmt.Sprintf("%s/index.html", targetDir))
-			}
-		})
-	}
+	// ========== 前端项目：由 [frontend] 配置与 ci.BinFrontend 声明，统一处理 history 路由 ==========
+	frontends := ci.GetFrontendsList()
+	bindFrontendRoutes(R, frontends)
+
+	// ========== 健康检查：/healthz、/livez、/readyz ==========
+	BindHealthRoutes(R)
 
 	// ========== CORS 跨域配置（通过 config.ini [cors] 节控制） ==========
 	if ci.C("cors.enable") != "false" {
@@ -331,12 +324,16 @@ func InitRouter() *gin.Engine {
 	}
 
 	// ========== 原有中间件注册逻辑（保留） ==========
-	// 获取原始中间件切片（不做任何转换）
+	// 获取中间件切片（按 Priority() 与类型名排序）
 	middlewareList := ci.GetMiddlewaresList()
+	if err := validateMiddlewares(middlewareList); err != nil {
+		return nil, err
+	}
 
 	// 2. 创建 /api 路由组（与 /agent 共用同一套中间件链）
 	apiGroup := R.Group("/api")
-	registerAPIMiddlewareChain(apiGroup, middlewareList)
+	registerAPIMiddlewareChain(apiGroup, middlewareList, "api")
+	useGroupMiddlewares(apiGroup, "api")
 
 	// Agent HTTP：根路径 /agent（默认），由 ci.BinAgentRoutes 注入，与 /api 相同鉴权链
 	bindAgentHTTPRoutes(R, middlewareList)
@@ -346,13 +343,11 @@ func InitRouter() *gin.Engine {
 		path := c.Request.URL.Path
 		method := c.Request.Method
 
-		// 1. 判断是否为子前端项目路径（admin/h5/merchant/store）
+		// 1. 判断是否为子前端项目路径
 		// 这些路径已经在 GET /*any 中注册，若走到此处说明是该项目内部的404资源
-		for prefix := range frontEndProjects {
-			if strings.HasPrefix(path, prefix) {
-				c.Status(404)
-				return
-			}
+		if _, ok := matchFrontendMount(frontends, path); ok {
+			c.Status(404)
+			return
 		}
 
 		// 2. 如果是 API 或 Agent 请求，返回 JSON 404
@@ -361,56 +356,63 @@ func InitRouter() *gin.Engine {
 			return
 		}
 
-		// 3. 处理根项目 (views/web) 的静态资源和 history 路由
-		targetDir := "./views/web"
-		// 拼接完整文件路径
-		fullPath := filepath.Join(targetDir, strings.TrimPrefix(path, "/"))
-
-		// 检查文件是否存在且不是目录
-		if info, err := os.Stat(fullPath); err == nil && !info.IsDir() {
-			c.File(fullPath)
+		// 3. 处理根项目（默认 views/web）的静态资源和 history 路由
+		if serveRootFrontend(c, frontends) {
 			return
 		}
 
-		// 非静态资源（无后缀或后缀不匹配），返回根项目的 index.html 以支持 history 模式
-		// 如果是明确的静态资源请求（如 .js, .css）但文件不存在，则返回 404
-		ext := strings.ToLower(filepath.Ext(path))
-		isStatic := false
-		for _, e := range staticExts {
-			if ext == e {
-				isStatic = true
-				break
-			}
-		}
-
-		if isStatic {
+		if path == "/" || path == "" {
+			// 如果连 index.html 都没有，且访问的是根路径，则返回欢迎信息
+			c.JSON(200, gin.H{"code": 200, "message": "欢迎使用卡莱易框架"})
+		} else if root, ok := rootFrontendMount(frontends); ok && isStaticExt(path, root.Options.StaticExts) {
+			// 明确的静态资源请求（如 .js, .css）但文件不存在，返回 404
 			c.Status(404)
 		} else {
-			// 返回根项目的 index.html
-			indexPath := filepath.Join(targetDir, "index.html")
-			if _, err := os.Stat(indexPath); err == nil {
-				c.File(indexPath)
-			} else if path == "/" || path == "" {
-				// 如果连 index.html 都没有，且访问的是根路径，则返回欢迎信息
-				c.JSON(200, gin.H{"code": 200, "message": "欢迎使用卡莱易框架"})
-			} else {
-				// 其他不存在的路径返回 404
-				c.JSON(404, gin.H{"code": 404, "message": "您" + method + "请求地址：" + path + "不存在！"})
-			}
+			// 其他不存在的路径返回 404
+			c.JSON(404, gin.H{"code": 404, "message": "您" + method + "请求地址：" + path + "不存在！"})
 		}
 	})
 
+	// ========== 依赖注入：为控制器与服务的 inject 字段注入服务，缺失或有歧义时拒绝启动 ==========
+	if err := injectControllers(); err != nil {
+		return nil, err
+	}
+
 	// ========== 原有路由绑定逻辑（保留） ==========
-	//绑定基本路由，访问路径：/User/List
-	ci.Bind(R)
+	//绑定应用控制器路由到 apiGroup（继承JWT/Tenant验证）
+	ci.Bind(apiGroup, func(path string) []string {
+		return apiChainNames(middlewareList, "", path)
+	})
 	//绑定插件路由
-	BindSoftwareRoutes(R, apiGroup)
+	BindSoftwareRoutes(apiGroup)
+	//绑定系统路由：/api/system/*
+	bindSystemRoutes(R, apiGroup)
 
 	// ========== WebSocket 路由组（/ws，由 ci.BinWSController 注册） ==========
 	BindWSRoutes(R)
 
+	// ========== 接口文档（docs.enabled=true 时注册 /docs） ==========
+	BindDocsRoutes(R)
+
+	//控制器 Routes() 或 [middleware] 配置有误时拒绝启动
+	if err := ci.RouteError(); err != nil {
+		return nil, err
+	}
+
 	//返回实例
-	return R
+	return R, nil
+}
+
+// injectControllers 为应用与插件控制器注入依赖
+func injectControllers() error {
+	targets := ci.GetRegisteredControllers()
+	for _, c := range ControllersPool {
+		targets = append(targets, c)
+	}
+	if err := ci.InjectAll(targets...); err != nil {
+		return fmt.Errorf("依赖注入失败: %w", err)
+	}
+	return nil
 }
 
 // BindWSRoutes 创建 ws 路由组并注册所有通过 ci.BinWSController 绑定的控制器。
@@ -435,6 +437,7 @@ func BindWSRoutes(R *gin.Engine) {
 	fmt.Printf("[ws] 注册 WS 路由组 %s，控制器数: %d\n", prefix, len(handlers))
 	wsGroup := R.Group(prefix)
 	wsGroup.Use(middleware.WsVerify)
+	useGroupMiddlewares(wsGroup, "ws")
 	for _, fn := range handlers {
 		fn(wsGroup)
 	}
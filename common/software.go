@@ -8,6 +8,7 @@ import (
 	"unicode"
 
 	"github.com/gin-gonic/gin"
+	"github.com/qinuoyun/caleyi/utils/ci"
 )
 
 // Controller 定义控制器模块接口
@@ -34,15 +35,22 @@ type Software interface {
 
 // Route 路由结构体
 type Route struct {
-	path       string         //url路径
-	httpMethod string         //http方法 get post
-	Method     reflect.Value  //方法路由
-	Args       []reflect.Type //参数类型
+	path        string          //url路径
+	httpMethods []string        //按方法名推断的http方法
+	Method      reflect.Value   //方法路由
+	Args        []reflect.Type  //参数类型
+	handler     string          //控制器类型.方法名，用于路由清单
+	plugin      string          //所属插件名称
+	meta        ci.RouteMeta    //控制器 Routes() 声明的路由元数据
+	call        gin.HandlerFunc //按方法签名包装的处理函数（见 ci.BuildHandler）
 }
 
 // Routes 路由集合
 var Routes []Route
 
+// ControllersPool 插件控制器，构建路由时为其注入依赖（见 ci.InjectTag）
+var ControllersPool []Controller
+
 // ModulesPool 全局模块池 - 按AppName分组存储模块
 var ModulesPool = make(map[string][]Module)
 
@@ -98,22 +106,38 @@ func ConvertToMiddlewares(middlewares []interface{}) []Middlewares {
 	return result
 }
 
-// BindSoftwareRoutes Bind 绑定路由 m是方法GET POST等
-func BindSoftwareRoutes(e *gin.Engine, apiGroup *gin.RouterGroup) {
+// BindSoftwareRoutes 将插件路由注册到 apiGroup（继承JWT/Tenant验证）；不在 /api/ 下的路由不注册，记录到 ci.RouteError 并拒绝启动
+func BindSoftwareRoutes(apiGroup *gin.RouterGroup) {
+	middlewareList := ci.GetMiddlewaresList()
+	pluginHandlers, pluginNames := groupMiddlewares("plugin")
 	//fmt.Printf("====我是插件路由-查看路径名称%v\n", Routes)
 	for _, route := range Routes {
 		//fmt.Printf("查看路径名称%v\n", route.path)
-		// 只允许GET或者POST
-		if len(route.path) >= 4 && route.path[:4] == "/api" {
-			// /api 开头的路由 → 注册到 apiGroup（继承JWT/Tenant验证）
-			subPath := route.path[4:]
-			if subPath == "" {
-				subPath = "/"
+		owner := ci.RouteOwner{Handler: route.handler, Plugin: route.plugin}
+		owner.Request, owner.Response = ci.HandlerTypes(route.Method)
+		// 严格模式仅注册推断的方法，兼容模式同时注册 GET 与 POST（见 ci.RouteMethods）；Routes() 声明的方法优先
+		methods := route.meta.ResolveMethods(route.httpMethods)
+		// [middleware] plugin 配置的中间件 → Routes() 声明的限流与中间件 → action
+		metaHandlers, metaNames := route.meta.Handlers()
+		handlers := append(append([]gin.HandlerFunc{}, pluginHandlers...), metaHandlers...)
+		names := append(append([]string{}, pluginNames...), metaNames...)
+		if strings.Contains(route.path, "/:") {
+			handlers = append(handlers, ci.PathParamsToQuery)
+		}
+		handlers = append(handlers, matchPath(route.path, route))
+		if !strings.HasPrefix(route.path, "/api/") {
+			ci.AddRouteError(fmt.Errorf("插件路由 %s（%s）不在 /api/ 下，未注册", route.path, route.handler))
+			continue
+		}
+		apiGroup.Match(methods, route.path[len("/api"):], handlers...)
+		owner.Group = "api"
+		// 记录该路由实际生效的中间件链（按作用范围过滤），用于路由清单
+		owner.Middlewares = append(apiChainNames(middlewareList, route.plugin, route.path), names...)
+		for _, m := range methods {
+			ci.SetRouteOwner(m, route.path, owner)
+			if route.meta.Public {
+				ci.SetPublicRoute(m, route.path)
 			}
-			apiGroup.Match([]string{"GET", "POST"}, subPath, matchPath(route.path, route))
-		} else {
-			// 非 /api 开头的路由 → 直接注册到 gin.Engine（无认证）
-			e.Match([]string{"GET", "POST"}, route.path, matchPath(route.path, route))
 		}
 	}
 }
@@ -127,9 +151,7 @@ func matchPath(path string, route Route) gin.HandlerFunc {
 			return
 		}
 		if len(Routes) > 0 {
-			arguments := make([]reflect.Value, 1)
-			arguments[0] = reflect.ValueOf(c) // *gin.Context
-			route.Method.Call(arguments)
+			route.call(c)
 		}
 	}
 }
@@ -153,7 +175,13 @@ func registerControllerRoutes(controller interface{}, route string, prefix strin
 		v = v.Elem() // 获取指针指向的实际对象
 	}
 
+	ControllersPool = append(ControllersPool, controller)
 	fullPath := GetAdminMerchantPathByRegex(route)
+	ctrlName := ci.RemoveStarFromTypeName(controller)
+	base := "/api/" + prefix + "/" + fullPath
+	// 控制器可通过 Routes() 覆盖单个 action 的路径、方法、鉴权、限流与中间件
+	metas := ci.ControllerRouteMeta(controller)
+	ci.CheckRouteMeta(controller, metas)
 
 	// 遍历控制器的所有方法
 	for i := 0; i < v.NumMethod(); i++ {
@@ -164,18 +192,33 @@ func registerControllerRoutes(controller interface{}, route string, prefix strin
 		if !isPublicMethod(methodName) {
 			continue
 		}
+		meta := metas[methodName]
+		if meta.Skip || ci.IsRouteMetaMethod(controller, methodName) {
+			continue
+		}
 		// 生成路由路径：根路径 + 模块名 + 方法名（首字母小写）
-		path := "/api/" + prefix + "/" + fullPath + firstLower(methodName)
+		path := meta.ResolvePath(base, base+firstLower(methodName))
 		//fmt.Printf("============查看路径名称%v\n", path)
 
+		// 按方法签名包装处理函数：支持 func(c, *Req) (R, error) 等形式，不支持的签名跳过注册
+		call, err := ci.BuildHandler(method)
+		if err != nil {
+			fmt.Printf("[router] 跳过 %s.%s: %v\n", ctrlName, methodName, err)
+			continue
+		}
+
 		// 根据方法名前缀自动推断HTTP方法
-		httpMethod := inferHTTPMethod(methodName)
+		httpMethods := ci.InferHTTPMethods(methodName)
 
 		// 收集方法参数类型（用于依赖注入）
 		paramTypes := collectMethodParams(method)
 
 		// 注册主路由
-		registerRoute(path, method, paramTypes, httpMethod)
+		registerRoute(path, method, call, paramTypes, httpMethods, ctrlName+"."+methodName, prefix, meta)
+		// RESTful 路由：Detail → GET /goods/:id，Update → PUT，Delete → DELETE，静态路由保持不变
+		if rest, ok := ci.RESTRoute(base, methodName, meta); ok {
+			registerRoute(rest.Path, method, call, paramTypes, httpMethods, ctrlName+"."+methodName, prefix, rest)
+		}
 	}
 }
 
@@ -184,21 +227,6 @@ func isPublicMethod(methodName string) bool {
 	return len(methodName) > 0 && unicode.IsUpper(rune(methodName[0]))
 }
 
-// 推断HTTP方法
-func inferHTTPMethod(methodName string) string {
-	switch {
-	case methodName == "Index" ||
-		strings.HasPrefix(methodName, "Get") && !strings.HasPrefix(methodName, "GetPost"):
-		return "GET"
-	case strings.HasPrefix(methodName, "Del") || methodName == "Del":
-		return "DELETE"
-	case strings.HasPrefix(methodName, "Put") || methodName == "Put":
-		return "PUT"
-	default:
-		return "POST" // 默认使用POST
-	}
-}
-
 // 收集方法的参数类型
 func collectMethodParams(method reflect.Value) []reflect.Type {
 	paramTypes := make([]reflect.Type, 0, method.Type().NumIn())
@@ -209,12 +237,16 @@ func collectMethodParams(method reflect.Value) []reflect.Type {
 }
 
 // 注册单条路由
-func registerRoute(path string, method reflect.Value, params []reflect.Type, httpMethod string) {
+func registerRoute(path string, method reflect.Value, call gin.HandlerFunc, params []reflect.Type, httpMethods []string, handler string, plugin string, meta ci.RouteMeta) {
 	route := Route{
-		path:       path,
-		Method:     method,
-		Args:       params,
-		httpMethod: httpMethod,
+		path:        path,
+		Method:      method,
+		call:        call,
+		Args:        params,
+		httpMethods: httpMethods,
+		handler:     handler,
+		plugin:      plugin,
+		meta:        meta,
 	}
 	Routes = append(Routes, route)
 }
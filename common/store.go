@@ -0,0 +1,160 @@
+package common
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/qinuoyun/caleyi/utils/ci"
+	"gorm.io/gorm"
+)
+
+// UseTenantStores 按 tenant.mode 设置租户存储（OpenDB 中调用，外部提供的连接需自行调用）：
+//   - column：所有租户共用 shared，按 tenant_id 列隔离
+//   - schema：每个租户一个 schema（仅 PostgreSQL），连接的 search_path 指向该 schema
+//   - database：每个租户一个数据库，SQLite 为 sqlite.file 同目录下的文件
+//
+// 租户存储名见 ci.TenantStoreName，首次使用时创建并迁移，连接池按租户管理（见 ci.TenantStore）。
+func UseTenantStores(shared *gorm.DB) error {
+	if err := ci.CheckTenantMode(); err != nil {
+		return err
+	}
+	mode := ci.TenantMode()
+	if mode == ci.TenantModeColumn {
+		ci.SetTenantStoreOpener(nil, nil)
+		return nil
+	}
+	sqlType := shared.Dialector.Name()
+	if mode == ci.TenantModeSchema && sqlType != "postgres" {
+		return fmt.Errorf("tenant.mode=schema 仅支持 PostgreSQL（当前为 %s），请使用 database 模式", sqlType)
+	}
+	ci.SetTenantStoreOpener(func(tenantID string) (*gorm.DB, error) {
+		return openTenantStore(shared, sqlType, mode, tenantID)
+	}, migrateModules)
+	fmt.Printf("[tenant] 隔离模式: %s（%s）\n", mode, sqlType)
+	return nil
+}
+
+// openTenantStore 创建（不存在时）并连接租户的 schema / 数据库
+func openTenantStore(shared *gorm.DB, sqlType, mode, tenantID string) (*gorm.DB, error) {
+	name, err := ci.TenantStoreName(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	var dialector gorm.Dialector
+	switch {
+	case mode == ci.TenantModeSchema:
+		if err := shared.Exec(fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS "%s"`, name)).Error; err != nil {
+			return nil, fmt.Errorf("创建租户 schema %s 失败：%w", name, err)
+		}
+		dialector, _, err = dialectorFor(sqlType, "", name)
+	case sqlType == "sqlite":
+		dialector, _, err = dialectorFor(sqlType, filepath.Join(filepath.Dir(sqliteFile()), name+".db"), "")
+	default:
+		if err := createDatabase(shared, sqlType, name); err != nil {
+			return nil, err
+		}
+		dialector, _, err = dialectorFor(sqlType, name, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, GormConfig())
+	if err != nil {
+		return nil, fmt.Errorf("连接租户 %s 的数据库失败：%w", tenantID, err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("连接租户 %s 的数据库失败：%w", tenantID, err)
+	}
+	// 独立存储中仍保留 tenant_id 列与隔离插件，与 column 模式的数据保持一致；失败时关闭已打开的连接
+	if err := ci.UseTenantPlugin(db); err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+	maxOpen, maxIdle := ci.TenantPoolLimits()
+	sqlDB.SetMaxOpenConns(maxOpen)
+	sqlDB.SetMaxIdleConns(maxIdle)
+	fmt.Printf("[tenant] 已连接租户 %s 的存储 %s\n", tenantID, name)
+	return db, nil
+}
+
+// createDatabase 创建租户数据库（已存在时跳过）
+func createDatabase(shared *gorm.DB, sqlType, name string) error {
+	var err error
+	switch sqlType {
+	case "mysql":
+		err = shared.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s` DEFAULT CHARACTER SET utf8mb4", name)).Error
+	case "postgres":
+		// PostgreSQL 不支持 CREATE DATABASE IF NOT EXISTS
+		var count int64
+		if err = shared.Raw("SELECT COUNT(*) FROM pg_database WHERE datname = ?", name).Scan(&count).Error; err == nil && count == 0 {
+			err = shared.Exec(fmt.Sprintf(`CREATE DATABASE "%s"`, name)).Error
+			// 其它实例可能已同时创建
+			if err != nil && strings.Contains(err.Error(), "already exists") {
+				err = nil
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("创建租户数据库 %s 失败：%w", name, err)
+	}
+	return nil
+}
+
+// MigrateTenantStores 迁移 schema / database 模式下的全部租户存储：注册表中的租户、app.tenant_id、ws.default_tenant、
+// tenant.stores 中列出的租户与已打开的租户；其余租户在首次使用时迁移
+func MigrateTenantStores(shared *gorm.DB) error {
+	opened := make(map[string]bool)
+	for _, id := range ci.TenantStoreIDs() {
+		opened[id] = true
+	}
+	tenantIDs, err := knownTenants(shared)
+	if err != nil {
+		return err
+	}
+	for _, tenantID := range tenantIDs {
+		db, release, err := ci.AcquireTenantStore(tenantID)
+		if err != nil {
+			return fmt.Errorf("租户 %s 迁移失败：%w", tenantID, err)
+		}
+		// 新打开的存储已在打开时迁移
+		if opened[tenantID] {
+			err = migrateModules(db)
+		}
+		release()
+		if err != nil {
+			return fmt.Errorf("租户 %s 迁移失败：%w", tenantID, err)
+		}
+		fmt.Printf("[tenant] 租户 %s 迁移完成\n", tenantID)
+	}
+	return nil
+}
+
+// knownTenants 返回需要迁移的租户（已去重、排序）
+func knownTenants(shared *gorm.DB) ([]string, error) {
+	set := make(map[string]bool)
+	if ci.TenantRegistryEnabled() {
+		var ids []string
+		if err := shared.Model(&ci.Tenant{}).Pluck("tenant_id", &ids).Error; err != nil {
+			return nil, fmt.Errorf("读取租户注册表失败：%w", err)
+		}
+		for _, id := range ids {
+			set[id] = true
+		}
+	}
+	for _, id := range ci.TenantStoreIDs() {
+		set[id] = true
+	}
+	for _, id := range ci.ConfiguredTenants() {
+		set[id] = true
+	}
+	tenantIDs := make([]string, 0, len(set))
+	for id := range set {
+		tenantIDs = append(tenantIDs, id)
+	}
+	sort.Strings(tenantIDs)
+	return tenantIDs, nil
+}
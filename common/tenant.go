@@ -0,0 +1,155 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qinuoyun/caleyi/utils/ci"
+)
+
+// tenantAdmin 租户管理接口（tenant.registry=true 时注册），与其它系统路由一样仅 CrossTenant 账号（平台管理员）可调用
+type tenantAdmin struct{}
+
+// TenantListReq 租户列表参数
+type TenantListReq struct {
+	Status string `form:"status" binding:"omitempty,oneof=active suspended"`
+	Page   int    `form:"page" binding:"omitempty,min=1"`
+	Size   int    `form:"size" binding:"omitempty,min=1,max=100"`
+}
+
+// TenantListResp 租户列表
+type TenantListResp struct {
+	List  []ci.Tenant `json:"list"`
+	Total int64       `json:"total"`
+}
+
+// TenantIDReq 路径中的租户 ID
+type TenantIDReq struct {
+	TenantID string `uri:"tenant_id" json:"-" binding:"required"`
+}
+
+// TenantCreateReq 创建租户参数
+type TenantCreateReq struct {
+	TenantID string                 `json:"tenant_id" form:"tenant_id" binding:"required,tenantid"`
+	Name     string                 `json:"name" form:"name" binding:"max=100"`
+	Plan     string                 `json:"plan" form:"plan" binding:"max=32"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+// TenantUpdateReq 修改租户参数，未传的字段不修改，metadata 整体替换
+type TenantUpdateReq struct {
+	TenantIDReq
+	Name     *string                `json:"name" binding:"omitempty,max=100"`
+	Plan     *string                `json:"plan" binding:"omitempty,max=32"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+// TenantSuspendReq 停用租户参数
+type TenantSuspendReq struct {
+	TenantIDReq
+	Reason string `json:"reason" form:"reason" binding:"max=255"`
+}
+
+func (tenantAdmin) List(c *gin.Context, req *TenantListReq) (*TenantListResp, error) {
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Size == 0 {
+		req.Size = 20
+	}
+	list, total, err := ci.Tenants().List(c.Request.Context(), req.Status, req.Page, req.Size)
+	if err != nil {
+		return nil, err
+	}
+	return &TenantListResp{List: list, Total: total}, nil
+}
+
+func (tenantAdmin) Create(c *gin.Context, req *TenantCreateReq) (*ci.Tenant, error) {
+	t, err := ci.Tenants().Create(c.Request.Context(), &ci.Tenant{
+		TenantID: req.TenantID,
+		Name:     req.Name,
+		Plan:     req.Plan,
+		Metadata: req.Metadata,
+	})
+	return t, tenantError(err)
+}
+
+func (tenantAdmin) Get(c *gin.Context, req *TenantIDReq) (*ci.Tenant, error) {
+	t, err := ci.Tenants().Get(c.Request.Context(), req.TenantID)
+	return t, tenantError(err)
+}
+
+func (tenantAdmin) Update(c *gin.Context, req *TenantUpdateReq) (*ci.Tenant, error) {
+	t, err := ci.Tenants().Update(c.Request.Context(), req.TenantID, ci.TenantUpdate{
+		Name:     req.Name,
+		Plan:     req.Plan,
+		Metadata: req.Metadata,
+	})
+	return t, tenantError(err)
+}
+
+func (tenantAdmin) Suspend(c *gin.Context, req *TenantSuspendReq) (*ci.Tenant, error) {
+	t, err := ci.Tenants().Suspend(c.Request.Context(), req.TenantID, req.Reason)
+	return t, tenantError(err)
+}
+
+func (tenantAdmin) Reactivate(c *gin.Context, req *TenantIDReq) (*ci.Tenant, error) {
+	t, err := ci.Tenants().Reactivate(c.Request.Context(), req.TenantID)
+	return t, tenantError(err)
+}
+
+func (tenantAdmin) Delete(c *gin.Context, req *TenantIDReq) error {
+	return tenantError(ci.Tenants().Delete(c.Request.Context(), req.TenantID))
+}
+
+// tenantError 将注册表错误转换为带错误码的错误：不存在 40401，已存在 40901
+func tenantError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ci.ErrTenantNotFound):
+		return ci.NewCodeError(40401, err.Error())
+	case errors.Is(err, ci.ErrTenantExists):
+		return ci.NewCodeError(40901, err.Error())
+	}
+	return err
+}
+
+// bindTenantRoutes 注册租户管理接口（tenant.registry=true 时）：
+//
+//	GET    /api/system/tenants                        租户列表（?status=&page=&size=）
+//	POST   /api/system/tenants                        创建租户
+//	GET    /api/system/tenants/:tenant_id             租户详情
+//	PUT    /api/system/tenants/:tenant_id             修改名称、套餐、元数据
+//	POST   /api/system/tenants/:tenant_id/suspend     停用
+//	POST   /api/system/tenants/:tenant_id/reactivate  恢复
+//	DELETE /api/system/tenants/:tenant_id             删除（软删除）
+func bindTenantRoutes(systemG *gin.RouterGroup) {
+	if !ci.TenantRegistryEnabled() {
+		return
+	}
+	var admin tenantAdmin
+	g := systemG.Group("/tenants")
+	routes := []struct {
+		method, path string
+		action       interface{}
+	}{
+		{"GET", "", admin.List},
+		{"POST", "", admin.Create},
+		{"GET", "/:tenant_id", admin.Get},
+		{"PUT", "/:tenant_id", admin.Update},
+		{"POST", "/:tenant_id/suspend", admin.Suspend},
+		{"POST", "/:tenant_id/reactivate", admin.Reactivate},
+		{"DELETE", "/:tenant_id", admin.Delete},
+	}
+	for _, r := range routes {
+		handler, err := ci.BuildHandler(reflect.ValueOf(r.action))
+		if err != nil {
+			fmt.Printf("[tenant] 注册租户管理接口 %s %s 失败: %v\n", r.method, r.path, err)
+			continue
+		}
+		g.Handle(r.method, r.path, handler)
+	}
+}
@@ -21,6 +21,20 @@ type UserClaims struct {
 	Openid     string `json:"openid"`      // 微信openid
 	Module     string `json:"module"`      // 账号模型是前端 还是 商户 还是 后端
 	Username   string `json:"username"`
+	// TenantID 签发时所属租户，TenantVerify / WsVerify 拒绝解析出的租户与之不同的请求（403）
+	TenantID string `json:"tenant_id,omitempty"`
+	// CrossTenant 平台管理员等可跨租户访问的账号，为 true 时不校验租户一致性
+	CrossTenant bool `json:"cross_tenant,omitempty"`
+}
+
+// GetUserClaims 获取 JwtVerify / WsVerify 写入的当前用户声明
+func GetUserClaims(c *gin.Context) (UserClaims, bool) {
+	v, ok := c.Get("user")
+	if !ok {
+		return UserClaims{}, false
+	}
+	claims, ok := v.(UserClaims)
+	return claims, ok
 }
 
 // CustomClaims 自定义声明结构体，嵌入标准声明
@@ -57,7 +71,8 @@ func TokenOutTime(claims *UserClaims) int64 {
 	return time.Now().Add(EffectTime).Unix()
 }
 
-// GenerateToken 生成token
+// GenerateToken 生成token，claims.TenantID 随 token 签发（请求内签发可使用 GenerateTokenFor 自动填充）；
+// TenantID 为空且非 CrossTenant 的 token 会被 TenantVerify / WsVerify 拒绝（见 tenant.require_token_tenant）
 func GenerateToken(claims *UserClaims) (string, error) {
 	// 设置自定义声明和标准声明
 	customClaims := CustomClaims{
@@ -79,6 +94,16 @@ func GenerateToken(claims *UserClaims) (string, error) {
 	return signedToken, nil
 }
 
+// GenerateTokenFor 为当前请求的租户签发 token：claims.TenantID 为空且不可跨租户时取 ci.GetTenantID(c)
+//
+//	token, err := middleware.GenerateTokenFor(c, &middleware.UserClaims{ID: user.ID, Module: "business"})
+func GenerateTokenFor(c *gin.Context, claims *UserClaims) (string, error) {
+	if claims.TenantID == "" && !claims.CrossTenant {
+		claims.TenantID = ci.GetTenantID(c)
+	}
+	return GenerateToken(claims)
+}
+
 // JwtVerify 验证token
 func JwtVerify(c *gin.Context) {
 	// 获取白名单列表
@@ -93,6 +118,10 @@ func JwtVerify(c *gin.Context) {
 	if checkWhiteList(whiteList, c.Request.URL.Path) { // 不需要token验证的路径
 		return
 	}
+	// 控制器 Routes() 声明为 Public 的路由
+	if ci.IsPublicRoute(c.Request.Method, c.FullPath()) {
+		return
+	}
 
 	// 从请求头获取token
 	token := c.GetHeader("Authorization")
@@ -137,6 +166,7 @@ func JwtVerify(c *gin.Context) {
 	c.Set("user", claims.UserClaims)
 
 	c.Set("uid", claims.UserClaims.ID)
+	ci.RequestScope(c).UID = claims.UserClaims.ID
 
 	// 判断 claims.UserClaims.module 是否为空
 	if claims.UserClaims.Module == "" {
@@ -187,6 +217,11 @@ func Refresh(tokenString string) (string, error) {
 	return GenerateToken(&claims.UserClaims)
 }
 
+// IsWhitelisted 判断路径是否在 whitelist.items 白名单中（无需 token）
+func IsWhitelisted(path string) bool {
+	return checkWhiteList(strings.Split(ci.C("whitelist.items"), ","), path)
+}
+
 // 检查白名单
 func checkWhiteList(whiteList []string, path string) bool {
 	for _, p := range whiteList {
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/qinuoyun/caleyi/utils/ci"
+)
+
+// bodyPeekLimit body 解析器最多读取的请求体字节数，超出部分不参与解析
+const bodyPeekLimit = 1 << 20
+
+// 内置租户解析器，在 [tenant_resolver] 中按 名称[:参数] 引用：
+//
+//	header[:名称]     请求头，默认 tenant_id
+//	query[:名称]      查询参数，默认 tenant_id
+//	host              域名映射 tenant_resolver.host_map（域名=租户），其次为 tenant_resolver.subdomain_suffix 下的子域名
+//	path:序号         路径段（从 1 开始），如 /t/{tenant}/... 使用 path:2
+//	jwt[:声明]        token 中的声明，默认 tenant_id（Authorization 头或 ?token=）
+//	body[:字段]       JSON 或表单请求体中的字段，默认 tenant_id；读取后还原请求体，不影响后续绑定
+//	default[:配置项]  配置中的默认租户，默认 app.tenant_id
+func init() {
+	ci.BinTenantResolver("header", func(c *gin.Context, args ...string) string {
+		return c.GetHeader(resolverArg(args, "tenant_id"))
+	})
+	ci.BinTenantResolver("query", func(c *gin.Context, args ...string) string {
+		return c.Query(resolverArg(args, "tenant_id"))
+	})
+	ci.BinTenantResolver("host", resolveTenantFromHost)
+	ci.BinTenantResolver("path", func(c *gin.Context, args ...string) string {
+		index, err := strconv.Atoi(resolverArg(args, ""))
+		segments := strings.Split(strings.Trim(c.Request.URL.Path, "/"), "/")
+		if err != nil || index < 1 || index > len(segments) {
+			return ""
+		}
+		return segments[index-1]
+	})
+	ci.BinTenantResolver("jwt", resolveTenantFromJwt)
+	ci.BinTenantResolver("body", resolveTenantFromBody)
+	ci.BinTenantResolver("default", func(c *gin.Context, args ...string) string {
+		return ci.C(resolverArg(args, "app.tenant_id"))
+	})
+}
+
+func resolverArg(args []string, def string) string {
+	if len(args) > 0 && args[0] != "" {
+		return args[0]
+	}
+	return def
+}
+
+// resolveTenantFromHost 按域名解析：先查 host_map（如 a.example.com=t1,b.example.com=t2），再取 subdomain_suffix（如 .example.com）前的子域名
+func resolveTenantFromHost(c *gin.Context, _ ...string) string {
+	host := c.Request.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	for _, item := range strings.Split(ci.C("tenant_resolver.host_map"), ",") {
+		domain, tenantID, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if ok && strings.EqualFold(strings.TrimSpace(domain), host) {
+			return strings.TrimSpace(tenantID)
+		}
+	}
+	suffix := strings.ToLower(strings.TrimSpace(ci.C("tenant_resolver.subdomain_suffix")))
+	if suffix == "" {
+		return ""
+	}
+	if !strings.HasPrefix(suffix, ".") {
+		suffix = "." + suffix
+	}
+	sub := strings.TrimSuffix(host, suffix)
+	if sub == host || sub == "" || strings.Contains(sub, ".") || sub == "www" {
+		return ""
+	}
+	return sub
+}
+
+// resolveTenantFromJwt 从 token 声明中读取租户（UserClaims.TenantID 或指定声明），token 无效时不命中
+func resolveTenantFromJwt(c *gin.Context, args ...string) string {
+	// JwtVerify / WsVerify 已解析的声明
+	if claims, ok := GetUserClaims(c); ok && resolverArg(args, "tenant_id") == "tenant_id" {
+		return claims.TenantID
+	}
+	token := c.GetHeader("Authorization")
+	if token == "" {
+		token = c.Query("token")
+	}
+	token = strings.TrimPrefix(token, "Bearer ")
+	if token == "" {
+		return ""
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return secret, nil
+	})
+	if err != nil {
+		return ""
+	}
+	switch v := claims[resolverArg(args, "tenant_id")].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// resolveTenantFromBody 读取请求体中的租户字段后还原请求体，后续 ShouldBind 等仍可正常读取
+func resolveTenantFromBody(c *gin.Context, args ...string) string {
+	if c.Request.Body == nil || c.Request.Method == "GET" {
+		return ""
+	}
+	contentType := c.ContentType()
+	if contentType != binding.MIMEJSON && contentType != binding.MIMEPOSTForm {
+		return ""
+	}
+	body := c.Request.Body
+	data, err := io.ReadAll(io.LimitReader(body, bodyPeekLimit+1))
+	// 还原请求体：已读取部分 + 未读取部分
+	c.Request.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(data), body), Closer: body}
+	if err != nil || len(data) > bodyPeekLimit {
+		return ""
+	}
+	field := resolverArg(args, "tenant_id")
+	if contentType == binding.MIMEPOSTForm {
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			return ""
+		}
+		return values.Get(field)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return ""
+	}
+	switch v := payload[field].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ResolveTenant 按 [tenant_resolver] 中分组（api / agent / ws）配置的顺序解析 tenant_id，
+// 命中后写入 gin 上下文 tenant_id 与 tenant_source（见 ci.GetTenantSource）
+func ResolveTenant(c *gin.Context, group string) string {
+	tenantID, source := ci.ResolveTenant(c, group)
+	if tenantID != "" {
+		c.Set("tenant_id", tenantID)
+		c.Set("tenant_source", source)
+	}
+	return tenantID
+}
@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -11,8 +11,22 @@ import (
 	"github.com/qinuoyun/caleyi/utils/ci"
 )
 
-// TenantVerify  验证token
+// 匹配 v1, v2, v3 等版本号格式
+var versionRegex = regexp.MustCompile(`^v\d+$`)
+
+// TenantVerify  验证token，按 tenant_resolver.api 解析 tenant_id
 func TenantVerify(c *gin.Context) {
+	tenantVerify(c, "api")
+}
+
+// TenantVerifyGroup 返回按指定分组（api / agent）的 [tenant_resolver] 配置解析 tenant_id 的 TenantVerify
+func TenantVerifyGroup(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantVerify(c, group)
+	}
+}
+
+func tenantVerify(c *gin.Context, group string) {
 	authStr := ci.C("tenant.auth")
 	// 使用 strconv.ParseBool 将字符串转换为布尔值
 	auth, err := strconv.ParseBool(authStr)
@@ -23,9 +37,6 @@ func TenantVerify(c *gin.Context) {
 		return
 	}
 	if auth {
-		// 编译正则表达式，匹配 v1, v2, v3 等版本号格式
-		versionRegex := regexp.MustCompile(`^v\d+$`)
-
 		// 获取请求路径并去除首尾斜杠
 		path := strings.Trim(c.Request.URL.Path, "/")
 
@@ -50,42 +61,39 @@ func TenantVerify(c *gin.Context) {
 
 		// 满足所有条件时执行的逻辑
 
-		// 尝试从请求头获取 tenant_id
-		tenantID := c.GetHeader("tenant_id")
-		if tenantID == "" {
-			// 若请求头中没有，尝试从 GET 参数获取
-			tenantID = c.Query("tenant_id")
-		}
-		if tenantID == "" {
-			// 若 GET 参数中没有，尝试从 POST 的 JSON 数据获取
-			var data map[string]interface{}
-			if err := c.BindJSON(&data); err == nil {
-				if id, ok := data["tenant_id"].(string); ok {
-					tenantID = id
-				}
-			}
+		// 按解析器链获取 tenant_id（header / query / host / path / jwt / body / default 等），不消费请求体
+		// 与 token 中的租户比对，不一致时拒绝（跨租户账号除外）
+		tenantID, ok := checkTokenTenant(c, ResolveTenant(c, group))
+		if !ok {
+			return
 		}
 
 		// 检查是否获取到 tenant_id
 		if tenantID == "" {
-			c.AbortWithStatusJSON(400, gin.H{"error": "未提供 tenant_id，请通过请求头或 GET/POST 参数传递"})
+			c.AbortWithStatusJSON(400, gin.H{"error": "未提供 tenant_id，解析顺序: " + strings.Join(ci.TenantResolverSpecs(group), ", ")})
 			return
 		}
 
-		// 获取 GORM 数据库实例
-		db := ci.D()
+		// 启用租户注册表时，拒绝不存在或已停用的租户
+		if !checkTenantStatus(c, tenantID) {
+			return
+		}
 
-		// 将 tenant_id 放入 GORM 事务上下文中
-		ctx := context.WithValue(c.Request.Context(), "tenant_id", tenantID)
-		db = db.WithContext(ctx)
+		// 写入请求作用域与 context，ci.MC(c, model) / ci.GetDB(c) 自动获取带租户的 DB（schema / database 模式下为租户独立连接）
+		// 请求处理期间持有租户连接，避免耗时请求（如流式响应）中连接被空闲回收关闭
+		db, release, err := ci.BindTenant(c, tenantID)
+		if err != nil {
+			abortTenantStore(c, tenantID, err)
+			return
+		}
+		defer release()
 
-		// 绑定到当前 goroutine，ci.M() 自动获取
-		ci.BindDB(db)
-		defer ci.UnbindDB()
+		// 兼容已废弃的 ci.M()：绑定到当前 goroutine（每次绑定需解析调用栈），tenant.goroutine_db=false 时跳过
+		if ci.GoroutineDBEnabled() {
+			ci.BindDB(db)
+			defer ci.UnbindDB()
+		}
 
-		// 将 tenantID 存储到 gin 上下文中，供后续处理使用
-		c.Set("db", db)
-		c.Set("tenant_id", tenantID)
 		c.Next()
 	} else {
 		c.Next()
@@ -93,3 +101,63 @@ func TenantVerify(c *gin.Context) {
 	}
 
 }
+
+// checkTokenTenant 校验解析出的租户与 token 中的租户一致：
+//   - 未登录（白名单、Public 路由）或 CrossTenant 账号不校验
+//   - 租户来自 default 兜底（客户端未指定）或未解析到时使用 token 中的租户
+//   - 不一致时以 403 终止请求；token 未携带租户时同样拒绝（否则可通过请求头切换到任意租户），
+//     旧 token 迁移期间可配置 tenant.require_token_tenant=false 临时放行
+func checkTokenTenant(c *gin.Context, tenantID string) (string, bool) {
+	claims, ok := GetUserClaims(c)
+	if !ok || claims.CrossTenant {
+		return tenantID, true
+	}
+	if claims.TenantID == "" {
+		if ci.C("tenant.require_token_tenant") != "false" {
+			c.AbortWithStatusJSON(403, gin.H{"code": 403, "msg": "token 未绑定租户，请重新登录"})
+			return "", false
+		}
+		return tenantID, true
+	}
+	if tenantID == "" || ci.GetTenantSource(c) == "default" {
+		c.Set("tenant_id", claims.TenantID)
+		c.Set("tenant_source", "jwt")
+		return claims.TenantID, true
+	}
+	if tenantID != claims.TenantID {
+		c.AbortWithStatusJSON(403, gin.H{"code": 403, "msg": "无权访问该租户的数据"})
+		return "", false
+	}
+	return tenantID, true
+}
+
+// checkTenantStatus 启用租户注册表（tenant.registry=true）时校验租户存在且未停用（带缓存，见 ci.TenantService.Check）：
+// 不存在返回 404，已停用返回 403，查询失败返回 503
+func checkTenantStatus(c *gin.Context, tenantID string) bool {
+	if !ci.TenantRegistryEnabled() {
+		return true
+	}
+	_, err := ci.Tenants().Check(c.Request.Context(), tenantID)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ci.ErrTenantNotFound):
+		c.AbortWithStatusJSON(404, gin.H{"code": 404, "msg": "租户不存在: " + tenantID})
+	case errors.Is(err, ci.ErrTenantSuspended):
+		c.AbortWithStatusJSON(403, gin.H{"code": 403, "msg": "租户已停用: " + tenantID})
+	default:
+		fmt.Printf("[tenant] 校验租户 %s 失败: %v\n", tenantID, err)
+		c.AbortWithStatusJSON(503, gin.H{"code": 503, "msg": "租户校验失败，请稍后重试"})
+	}
+	return false
+}
+
+// abortTenantStore 租户存储（schema / database 模式）不可用时以 503 终止请求，租户未登记时返回 404
+func abortTenantStore(c *gin.Context, tenantID string, err error) {
+	if errors.Is(err, ci.ErrTenantNotFound) {
+		c.AbortWithStatusJSON(404, gin.H{"code": 404, "msg": "租户不存在: " + tenantID})
+		return
+	}
+	fmt.Printf("[tenant] 打开租户 %s 的存储失败: %v\n", tenantID, err)
+	c.AbortWithStatusJSON(503, gin.H{"code": 503, "msg": "租户数据库不可用，请稍后重试"})
+}
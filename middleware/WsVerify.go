@@ -1,7 +1,6 @@
 package middleware
 
 import (
-	"context"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -18,14 +17,18 @@ import (
 //  1. Header  Authorization: Bearer <token>
 //  2. Query   ?token=<token>
 //
-// tenant_id 解析优先级：
+// tenant_id 按 tenant_resolver.ws 解析，默认优先级：
 //  1. Header  tenant_id
 //  2. Query   ?tenant_id=
 //  3. Config  ws.default_tenant
 //  4. Config  app.tenant_id
 func WsVerify(c *gin.Context) {
 	if ci.C("ws.require_auth") == "false" {
-		wsInjectDB(c, wsResolveTenantID(c))
+		release, ok := wsInjectDB(c, wsResolveTenantID(c))
+		if !ok {
+			return
+		}
+		defer release()
 		c.Next()
 		return
 	}
@@ -58,39 +61,47 @@ func WsVerify(c *gin.Context) {
 
 	c.Set("user", claims.UserClaims)
 	c.Set("uid", claims.UserClaims.ID)
+	ci.RequestScope(c).UID = claims.UserClaims.ID
 	if claims.UserClaims.Module == "" {
 		c.Set("user_module", "user")
 	} else {
 		c.Set("user_module", claims.UserClaims.Module)
 	}
 
-	// ── 注入 DB（携带 tenant_id 的 GORM 实例） ────────────────────────────────
-	wsInjectDB(c, wsResolveTenantID(c))
+	// ── 注入 DB（携带 tenant_id 的 GORM 实例），租户须与 token 一致 ──────────
+	tenantID, ok := checkTokenTenant(c, wsResolveTenantID(c))
+	if !ok {
+		return
+	}
+	release, ok := wsInjectDB(c, tenantID)
+	if !ok {
+		return
+	}
+	defer release()
 	c.Next()
 }
 
-// wsResolveTenantID 按优先级解析 tenant_id
+// wsResolveTenantID 按 tenant_resolver.ws 解析 tenant_id
 func wsResolveTenantID(c *gin.Context) string {
-	if v := c.GetHeader("tenant_id"); v != "" {
-		return v
-	}
-	if v := c.Query("tenant_id"); v != "" {
-		return v
-	}
-	if v := ci.C("ws.default_tenant"); v != "" {
-		return v
-	}
-	return ci.C("app.tenant_id")
+	return ResolveTenant(c, "ws")
 }
 
 // wsInjectDB 将携带 tenant_id 的 DB 实例注入 gin 上下文
-// 效果等同于 TenantVerify 对 /api 路由所做的操作
-func wsInjectDB(c *gin.Context, tenantID string) {
-	db := ci.D()
-	if tenantID != "" {
-		ctx := context.WithValue(c.Request.Context(), "tenant_id", tenantID)
-		db = db.WithContext(ctx)
-		c.Set("tenant_id", tenantID)
+// 效果等同于 TenantVerify 对 /api 路由所做的操作；租户不存在、已停用或存储不可用时终止握手并返回 false。
+// 返回的 release 在 handler 返回（连接结束）后调用，期间租户连接不会被空闲回收关闭；
+// handler 返回后仍在其它 goroutine 中使用 DB 的连接需自行调用 ci.AcquireTenantStore 持有
+func wsInjectDB(c *gin.Context, tenantID string) (release func(), ok bool) {
+	if tenantID == "" {
+		c.Set("db", ci.D())
+		return func() {}, true
+	}
+	if !checkTenantStatus(c, tenantID) {
+		return nil, false
+	}
+	_, release, err := ci.BindTenant(c, tenantID)
+	if err != nil {
+		abortTenantStore(c, tenantID, err)
+		return nil, false
 	}
-	c.Set("db", db)
+	return release, true
 }
@@ -0,0 +1,131 @@
+package caleyi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qinuoyun/caleyi/middleware"
+	"github.com/qinuoyun/caleyi/utils/ci"
+)
+
+func TestMain(m *testing.M) {
+	// 读取仓库根目录的 config.ini
+	os.Setenv("APP_ENV", "development")
+	gin.SetMode(gin.TestMode)
+	os.Exit(runWithMachineID(m))
+}
+
+// runWithMachineID 签发与解析 token 需要硬件 UUID，Linux 未安装 dmidecode 时以返回固定 UUID 的脚本代替
+func runWithMachineID(m *testing.M) int {
+	if _, err := exec.LookPath("dmidecode"); runtime.GOOS != "linux" || err == nil {
+		return m.Run()
+	}
+	dir, err := os.MkdirTemp("", "dmidecode")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	script := "#!/bin/sh\necho 00000000-0000-0000-0000-000000000001\n"
+	if err := os.WriteFile(filepath.Join(dir, "dmidecode"), []byte(script), 0o755); err != nil {
+		panic(err)
+	}
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return m.Run()
+}
+
+type routeTestController struct{}
+
+func (routeTestController) Index(c *gin.Context)   { c.String(http.StatusOK, "index") }
+func (routeTestController) GetOpen(c *gin.Context) { c.String(http.StatusOK, "open") }
+
+func (routeTestController) Routes() map[string]ci.RouteMeta {
+	return map[string]ci.RouteMeta{"GetOpen": {Public: true}}
+}
+
+func init() {
+	ci.Register(&routeTestController{}, "example.com/demo/app/shop/controllers")
+}
+
+// newRouteTestApp 创建无数据库的 App，返回 routeTestController 的受保护与 Public 路由路径
+func newRouteTestApp(t *testing.T) (app *App, protected, public string) {
+	t.Helper()
+	app, err := New(WithoutDB(), WithRoutesFile(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { app.Stop(context.Background()) })
+
+	paths := map[string]string{}
+	for _, r := range app.Handler().(*gin.Engine).Routes() {
+		if owner, ok := ci.GetRouteOwner(r.Method, r.Path); ok && owner.Group == "app" {
+			paths[owner.Handler] = r.Path
+		}
+	}
+	protected, public = paths["caleyi.routeTestController.Index"], paths["caleyi.routeTestController.GetOpen"]
+	if protected == "" || public == "" {
+		t.Fatalf("应用路由未注册: %v", paths)
+	}
+	return app, protected, public
+}
+
+func TestAppRoutesUseAPIMiddleware(t *testing.T) {
+	app, protected, public := newRouteTestApp(t)
+
+	cases := []struct {
+		path string
+		want int
+	}{
+		{protected, http.StatusUnauthorized},
+		{public, http.StatusOK},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set("tenant_id", "t1")
+		app.Handler().ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("GET %s = %d %s, want %d", tc.path, w.Code, w.Body.String(), tc.want)
+		}
+	}
+}
+
+func TestTokenTenantCannotBeSwapped(t *testing.T) {
+	app, protected, _ := newRouteTestApp(t)
+	token := func(claims *middleware.UserClaims) string {
+		s, err := middleware.GenerateToken(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	unbound := token(&middleware.UserClaims{ID: 1})
+	bound := token(&middleware.UserClaims{ID: 1, TenantID: "t1"})
+	admin := token(&middleware.UserClaims{ID: 1, CrossTenant: true})
+
+	cases := []struct {
+		name, token, tenant string
+		want                int
+	}{
+		{"未绑定租户的 token 切换请求头", unbound, "t2", http.StatusForbidden},
+		{"绑定租户的 token 切换请求头", bound, "t2", http.StatusForbidden},
+		{"绑定租户的 token 访问本租户", bound, "t1", http.StatusOK},
+		{"跨租户账号", admin, "t2", http.StatusOK},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, protected, nil)
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+		req.Header.Set("tenant_id", tc.tenant)
+		app.Handler().ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: GET %s = %d %s, want %d", tc.name, protected, w.Code, w.Body.String(), tc.want)
+		}
+	}
+}
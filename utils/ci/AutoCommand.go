@@ -0,0 +1,57 @@
+package ci
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CommandFn 命令行子命令处理函数，args 为子命令之后的参数
+type CommandFn func(args []string) error
+
+// Command 命令行子命令
+type Command struct {
+	Name  string    // 子命令名称，如 migrate
+	Usage string    // 一行说明，用于 help 输出
+	Run   CommandFn // 处理函数
+}
+
+var commands map[string]Command
+
+func init() {
+	commands = make(map[string]Command)
+}
+
+// BinCommand 注册命令行子命令（插件可在 init() 中扩展），同名命令后注册的覆盖先注册的。
+//
+// 示例：
+//
+//	func init() {
+//	    ci.BinCommand("seed", "写入演示数据", func(args []string) error {
+//	        return seed.Run(ci.D())
+//	    })
+//	}
+func BinCommand(name, usage string, fn CommandFn) {
+	if name == "" || fn == nil {
+		return
+	}
+	if _, exists := commands[name]; exists {
+		fmt.Printf("警告: 命令 %s 已存在，将被覆盖\n", name)
+	}
+	commands[name] = Command{Name: name, Usage: usage, Run: fn}
+}
+
+// GetCommand 根据名称获取已注册的子命令
+func GetCommand(name string) (Command, bool) {
+	cmd, ok := commands[name]
+	return cmd, ok
+}
+
+// GetCommandsList 获取所有已注册的子命令（按名称排序）
+func GetCommandsList() []Command {
+	list := make([]Command, 0, len(commands))
+	for _, cmd := range commands {
+		list = append(list, cmd)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
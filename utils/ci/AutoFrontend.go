@@ -0,0 +1,304 @@
+package ci
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultStaticExts 默认静态资源后缀列表（命中且文件不存在时返回 404，避免被 history 路由拦截）
+var DefaultStaticExts = []string{".js", ".css", ".png", ".jpg", ".jpeg", ".gif", ".ico", ".svg", ".woff", ".woff2", ".ttf", ".map", ".json", ".txt"}
+
+// FrontendOptions 前端项目挂载选项
+type FrontendOptions struct {
+	Index          string   // 入口文件，默认 index.html
+	DisableHistory bool     // 关闭 history 兜底：未命中文件时返回 404 而非入口文件
+	StaticExts     []string // 静态资源后缀列表，为空时使用 DefaultStaticExts
+}
+
+// FrontendMount 前端项目挂载点
+type FrontendMount struct {
+	Prefix  string // URL 前缀，如 /admin；根项目为 /
+	Dir     string // 本地目录（以 fs.FS 挂载时为空）
+	FS      fs.FS  // 文件系统，本地目录优先解析为 BinAssetsFS 中的同名目录，否则为 os.DirFS
+	Options FrontendOptions
+}
+
+var frontendMounts = make(map[string]FrontendMount)
+
+// StaticMount 静态资源挂载点（不做 history 兜底，仅按路径返回文件）
+type StaticMount struct {
+	Prefix string // URL 前缀，如 /static
+	Dir    string // 本地目录（以 fs.FS 挂载时为空）
+	FS     fs.FS  // 文件系统
+}
+
+var staticMounts = make(map[string]StaticMount)
+
+// assetsFS 内嵌资源文件系统，配置中的相对目录优先在其中查找
+var assetsFS fs.FS
+
+// BinAssetsFS 注册内嵌资源文件系统（如 //go:embed views static），用于单文件部署。
+// 注册后 [frontend]、[static] 配置及 BinFrontend/BinStatic 传入的相对目录（如 ./views/admin）
+// 会优先在该文件系统中查找同名目录，不存在时再回退到磁盘（如可写的 ./uploads）。
+//
+// 示例：
+//
+//	//go:embed views static
+//	var assets embed.FS
+//
+//	func main() {
+//	    ci.BinAssetsFS(assets)
+//	    caleyi.BootStart()
+//	}
+func BinAssetsFS(fsys fs.FS) {
+	assetsFS = fsys
+}
+
+// SubFS 返回文件系统的子目录，目录不存在时返回 nil，
+// 用于将 //go:embed views 中的 views/admin 单独挂载：ci.BinFrontend("/admin", ci.SubFS(assets, "views/admin"))
+func SubFS(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		return nil
+	}
+	return sub
+}
+
+// resolveDirFS 将目录解析为文件系统：优先使用 BinAssetsFS 中的同名目录，否则使用磁盘目录
+func resolveDirFS(dir string) fs.FS {
+	if assetsFS != nil && !filepath.IsAbs(dir) {
+		name := path.Clean(strings.TrimPrefix(filepath.ToSlash(dir), "./"))
+		if info, err := fs.Stat(assetsFS, name); err == nil && info.IsDir() {
+			if sub := SubFS(assetsFS, name); sub != nil {
+				return sub
+			}
+		}
+	}
+	return os.DirFS(dir)
+}
+
+// DocsAssetsFS 返回 docs.assets 配置的 Swagger UI 资源目录（swagger-ui-dist 发行包，需含 swagger-ui.css 与 swagger-ui-bundle.js），
+// 相对目录同样优先在 BinAssetsFS 中查找；未配置时返回 nil，接口文档页面使用内置查看器
+func DocsAssetsFS() fs.FS {
+	dir := strings.TrimSpace(C("docs.assets"))
+	if dir == "" {
+		return nil
+	}
+	return resolveDirFS(dir)
+}
+
+// BinStatic 注册静态资源挂载，source 为本地目录（string）或 fs.FS，同一前缀后注册的覆盖先注册的
+//
+//	ci.BinStatic("/static", ci.SubFS(assets, "static"))
+func BinStatic(prefix string, source interface{}) bool {
+	prefix = normalizeFrontendPrefix(prefix)
+	mount := StaticMount{Prefix: prefix}
+	switch v := source.(type) {
+	case string:
+		mount.Dir = v
+	case fs.FS:
+		mount.FS = v
+	default:
+		fmt.Printf("警告: 静态资源挂载 %s 的 source 类型 %T 不支持，仅支持目录字符串或 fs.FS\n", prefix, source)
+		return false
+	}
+	staticMounts[prefix] = mount
+	return true
+}
+
+// GetStaticsList 获取所有静态资源挂载（含 [static] 配置项），按前缀排序。
+//
+//	static.mounts  前缀:目录，多个用逗号分隔；未配置时默认挂载 /static、/public、/uploads
+func GetStaticsList() []StaticMount {
+	mounts := make(map[string]StaticMount)
+	specs := splitTrim(C("static.mounts"))
+	if len(specs) == 0 {
+		specs = []string{"/static:./static", "/public:./public", "/uploads:./uploads"}
+	}
+	for _, spec := range specs {
+		prefix, dir, found := strings.Cut(spec, ":")
+		if !found || strings.TrimSpace(prefix) == "" || strings.TrimSpace(dir) == "" {
+			fmt.Printf("警告: static.mounts 配置格式错误: %q（应为 前缀:目录）\n", spec)
+			continue
+		}
+		prefix = normalizeFrontendPrefix(prefix)
+		mounts[prefix] = StaticMount{Prefix: prefix, Dir: strings.TrimSpace(dir)}
+	}
+	// 代码注册的挂载优先于配置
+	for prefix, m := range staticMounts {
+		mounts[prefix] = m
+	}
+	list := make([]StaticMount, 0, len(mounts))
+	for _, m := range mounts {
+		// 目录在构建列表时才解析，保证 BinAssetsFS 的调用顺序不受 init 顺序影响
+		if m.FS == nil {
+			m.FS = resolveDirFS(m.Dir)
+		}
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Prefix < list[j].Prefix })
+	return list
+}
+
+// BinFrontend 注册前端项目（SPA）挂载，source 为本地目录（string）或 fs.FS（如 embed.FS），同一前缀后注册的覆盖先注册的。
+// prefix 为 / 时作为根项目，在其它路由均未命中时兜底。
+//
+// 示例：
+//
+//	func init() {
+//	    ci.BinFrontend("/partner", "./views/partner")
+//	    ci.BinFrontend("/kiosk", "./views/kiosk", ci.FrontendOptions{Index: "main.html", DisableHistory: true})
+//	}
+func BinFrontend(prefix string, source interface{}, opts ...FrontendOptions) bool {
+	prefix = normalizeFrontendPrefix(prefix)
+	mount := FrontendMount{Prefix: prefix}
+	switch v := source.(type) {
+	case string:
+		mount.Dir = v
+	case fs.FS:
+		mount.FS = v
+	default:
+		fmt.Printf("警告: 前端挂载 %s 的 source 类型 %T 不支持，仅支持目录字符串或 fs.FS\n", prefix, source)
+		return false
+	}
+	if len(opts) > 0 {
+		mount.Options = opts[0]
+	}
+	frontendMounts[prefix] = normalizeFrontendMount(mount)
+	return true
+}
+
+// GetFrontendsList 获取所有前端挂载（含 [frontend] 配置项），按前缀长度倒序，便于最长前缀匹配。
+//
+//	frontend.mounts       子项目挂载：前缀:目录[|index=入口文件][|history=false]，多个用逗号分隔；
+//	                      未配置时默认挂载 /admin、/h5、/merchant、/store（./views/ 下同名目录）
+//	frontend.root         根项目目录，默认 ./views/web
+//	frontend.index        默认入口文件，默认 index.html
+//	frontend.history      默认是否开启 history 兜底，默认 true
+//	frontend.static_exts  默认静态资源后缀列表
+func GetFrontendsList() []FrontendMount {
+	mounts := make(map[string]FrontendMount)
+	for _, m := range configFrontendMounts() {
+		mounts[m.Prefix] = m
+	}
+	// 代码注册的挂载优先于配置
+	for prefix, m := range frontendMounts {
+		mounts[prefix] = m
+	}
+	list := make([]FrontendMount, 0, len(mounts))
+	for _, m := range mounts {
+		// 目录在构建列表时才解析，保证 BinAssetsFS 的调用顺序不受 init 顺序影响
+		if m.FS == nil {
+			m.FS = resolveDirFS(m.Dir)
+		}
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if len(list[i].Prefix) == len(list[j].Prefix) {
+			return list[i].Prefix < list[j].Prefix
+		}
+		return len(list[i].Prefix) > len(list[j].Prefix)
+	})
+	return list
+}
+
+// configFrontendMounts 解析 [frontend] 配置
+func configFrontendMounts() []FrontendMount {
+	defaults := FrontendOptions{
+		Index:          C("frontend.index"),
+		DisableHistory: C("frontend.history") == "false",
+	}
+	if exts := C("frontend.static_exts"); exts != "" {
+		defaults.StaticExts = splitTrim(exts)
+	}
+
+	specs := splitTrim(C("frontend.mounts"))
+	if len(specs) == 0 {
+		specs = []string{"/admin:./views/admin", "/h5:./views/h5", "/merchant:./views/merchant", "/store:./views/store"}
+	}
+
+	var mounts []FrontendMount
+	for _, spec := range specs {
+		m, err := parseFrontendSpec(spec, defaults)
+		if err != nil {
+			fmt.Printf("警告: %v\n", err)
+			continue
+		}
+		mounts = append(mounts, m)
+	}
+
+	root := C("frontend.root")
+	if root == "" {
+		root = "./views/web"
+	}
+	mounts = append(mounts, normalizeFrontendMount(FrontendMount{Prefix: "/", Dir: root, Options: defaults}))
+	return mounts
+}
+
+// parseFrontendSpec 解析单条挂载配置：/kiosk:./views/kiosk|index=main.html|history=false
+func parseFrontendSpec(spec string, defaults FrontendOptions) (FrontendMount, error) {
+	parts := strings.Split(spec, "|")
+	prefix, dir, found := strings.Cut(parts[0], ":")
+	if !found || strings.TrimSpace(prefix) == "" || strings.TrimSpace(dir) == "" {
+		return FrontendMount{}, fmt.Errorf("frontend.mounts 配置格式错误: %q（应为 前缀:目录）", spec)
+	}
+	opts := defaults
+	for _, kv := range parts[1:] {
+		key, val, _ := strings.Cut(kv, "=")
+		switch strings.TrimSpace(key) {
+		case "index":
+			opts.Index = strings.TrimSpace(val)
+		case "history":
+			opts.DisableHistory = strings.TrimSpace(val) == "false"
+		default:
+			return FrontendMount{}, fmt.Errorf("frontend.mounts 不支持的选项: %q", kv)
+		}
+	}
+	dir = strings.TrimSpace(dir)
+	return normalizeFrontendMount(FrontendMount{
+		Prefix:  normalizeFrontendPrefix(prefix),
+		Dir:     dir,
+		Options: opts,
+	}), nil
+}
+
+// normalizeFrontendPrefix 统一前缀格式：以 / 开头、不以 / 结尾（根项目为 /）
+func normalizeFrontendPrefix(prefix string) string {
+	return "/" + strings.Trim(strings.TrimSpace(prefix), "/")
+}
+
+// normalizeFrontendMount 填充默认选项
+func normalizeFrontendMount(m FrontendMount) FrontendMount {
+	if m.Options.Index == "" {
+		m.Options.Index = "index.html"
+	}
+	if len(m.Options.StaticExts) == 0 {
+		m.Options.StaticExts = DefaultStaticExts
+	}
+	exts := make([]string, 0, len(m.Options.StaticExts))
+	for _, ext := range m.Options.StaticExts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		exts = append(exts, ext)
+	}
+	m.Options.StaticExts = exts
+	return m
+}
+
+// splitTrim 按逗号分隔并去除空项
+func splitTrim(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,223 @@
+package ci
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// 自动路由的错误码，与开发规范保持一致
+const (
+	CodeInvalidParams = 40001 // 参数绑定或校验失败
+	CodeFailed        = 50001 // action 返回错误
+)
+
+// CodeError 携带业务错误码的错误，typed action 返回时按该错误码响应
+//
+//	return nil, ci.NewCodeError(40401, "商品不存在")
+type CodeError struct {
+	Code int
+	Msg  string
+}
+
+func (e *CodeError) Error() string {
+	return e.Msg
+}
+
+// NewCodeError 创建带错误码的错误
+func NewCodeError(code int, msg string) *CodeError {
+	return &CodeError{Code: code, Msg: msg}
+}
+
+var (
+	contextType = reflect.TypeOf(&gin.Context{})
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// BuildHandler 将控制器方法包装为 gin.HandlerFunc，支持以下签名（Req 为结构体或其指针）：
+//
+//	func(c *gin.Context)
+//	func(c *gin.Context) error
+//	func(c *gin.Context) (R, error)
+//	func(c *gin.Context, req *Req) error
+//	func(c *gin.Context, req *Req) (R, error)
+//
+// 带 req 参数时依次绑定查询参数（form 标签）、请求体（JSON 或表单）与路径参数（uri 标签），
+// 再执行 binding 标签校验，失败时返回 CodeInvalidParams，data.errors 为字段错误列表（见 ValidationFailed）。
+// 带返回值时：action 已写出响应则不再处理；返回错误时以 ci.Error 响应（CodeError 使用其错误码与消息，
+// 其它错误记录日志后以 CodeFailed 返回通用消息）；
+// 否则以 ci.Success 返回结果。不支持的签名返回错误，由调用方跳过注册。
+func BuildHandler(method reflect.Value) (gin.HandlerFunc, error) {
+	t := method.Type()
+	if t.NumIn() == 0 || t.NumIn() > 2 || t.In(0) != contextType {
+		return nil, fmt.Errorf("不支持的方法签名 %s：第一个参数必须为 *gin.Context", t)
+	}
+	var reqType reflect.Type
+	if t.NumIn() == 2 {
+		reqType = t.In(1)
+		base := reqType
+		if base.Kind() == reflect.Ptr {
+			base = base.Elem()
+		}
+		if base.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("不支持的方法签名 %s：请求参数必须为结构体或结构体指针", t)
+		}
+	}
+	switch t.NumOut() {
+	case 0:
+	case 1:
+		if t.Out(0) != errorType {
+			return nil, fmt.Errorf("不支持的方法签名 %s：单个返回值必须为 error", t)
+		}
+	case 2:
+		if t.Out(1) != errorType {
+			return nil, fmt.Errorf("不支持的方法签名 %s：第二个返回值必须为 error", t)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的方法签名 %s：返回值过多", t)
+	}
+
+	// 最常见的 func(c *gin.Context) 直接调用，避免额外开销
+	if reqType == nil && t.NumOut() == 0 {
+		return func(c *gin.Context) {
+			method.Call([]reflect.Value{reflect.ValueOf(c)})
+		}, nil
+	}
+
+	return func(c *gin.Context) {
+		args := []reflect.Value{reflect.ValueOf(c)}
+		if reqType != nil {
+			req, err := bindRequest(c, reqType)
+			if err != nil {
+				ValidationFailed(c, err)
+				return
+			}
+			args = append(args, req)
+		}
+		out := method.Call(args)
+		if len(out) == 0 {
+			return
+		}
+		// action 已自行写出响应时不再处理返回值
+		if c.Writer.Written() {
+			return
+		}
+		if errVal := out[len(out)-1]; !errVal.IsNil() {
+			writeError(c, errVal.Interface().(error))
+			return
+		}
+		var data interface{}
+		if len(out) == 2 {
+			data = out[0].Interface()
+		}
+		Success(c, data)
+	}, nil
+}
+
+// HandlerTypes 返回 typed action 的请求参数类型与返回值类型（已去除指针），不存在时为 nil
+func HandlerTypes(method reflect.Value) (req reflect.Type, resp reflect.Type) {
+	if !method.IsValid() {
+		return nil, nil
+	}
+	t := method.Type()
+	if t.NumIn() == 2 {
+		req = t.In(1)
+	}
+	if t.NumOut() == 2 {
+		resp = t.Out(0)
+	}
+	for req != nil && req.Kind() == reflect.Ptr {
+		req = req.Elem()
+	}
+	for resp != nil && resp.Kind() == reflect.Ptr {
+		resp = resp.Elem()
+	}
+	return req, resp
+}
+
+// bindRequest 创建并绑定请求参数，返回与 reqType 相同类型的值
+func bindRequest(c *gin.Context, reqType reflect.Type) (reflect.Value, error) {
+	isPtr := reqType.Kind() == reflect.Ptr
+	base := reqType
+	if isPtr {
+		base = reqType.Elem()
+	}
+	ptr := reflect.New(base)
+	req := ptr.Interface()
+
+	if err := binding.MapFormWithTag(req, c.Request.URL.Query(), "form"); err != nil {
+		return reflect.Value{}, err
+	}
+	if err := bindBody(c, req); err != nil {
+		return reflect.Value{}, TranslateValidation(c, err)
+	}
+	// 路径参数最后绑定，优先级最高
+	if len(c.Params) > 0 {
+		params := make(map[string][]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = []string{p.Value}
+		}
+		if err := binding.MapFormWithTag(req, params, "uri"); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	if err := Validate(c, req); err != nil {
+		return reflect.Value{}, err
+	}
+	if isPtr {
+		return ptr, nil
+	}
+	return ptr.Elem(), nil
+}
+
+// bindBody 按 Content-Type 绑定请求体（不做校验，由 bindRequest 统一校验）
+func bindBody(c *gin.Context, req interface{}) error {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody || c.Request.Method == http.MethodGet {
+		return nil
+	}
+	switch c.ContentType() {
+	case binding.MIMEJSON:
+		err := json.NewDecoder(c.Request.Body).Decode(req)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	case binding.MIMEPOSTForm:
+		if err := c.Request.ParseForm(); err != nil {
+			return err
+		}
+		return binding.MapFormWithTag(req, c.Request.PostForm, "form")
+	case binding.MIMEMultipartPOSTForm:
+		if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+			return err
+		}
+		return binding.MapFormWithTag(req, c.Request.MultipartForm.Value, "form")
+	case "":
+		return nil
+	default:
+		return binding.Default(c.Request.Method, c.ContentType()).Bind(c.Request, req)
+	}
+}
+
+// writeError 将 action 返回的错误写为统一响应
+func writeError(c *gin.Context, err error) {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		ValidationFailed(c, err)
+		return
+	}
+	var codeErr *CodeError
+	if errors.As(err, &codeErr) {
+		Error(c, codeErr.Code, codeErr.Msg)
+		return
+	}
+	// 其它错误可能含 SQL、路径等内部信息，仅记录日志，对外返回通用消息
+	fmt.Printf("[handler] %s %s 失败: %v\n", c.Request.Method, c.FullPath(), err)
+	Error(c, CodeFailed, "服务器内部错误")
+}
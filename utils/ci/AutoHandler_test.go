@@ -0,0 +1,51 @@
+package ci
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type handlerTestController struct{}
+
+func (handlerTestController) Internal(c *gin.Context) (interface{}, error) {
+	return nil, errors.New("dial tcp 10.0.0.1:3306: connection refused")
+}
+
+func (handlerTestController) Coded(c *gin.Context) (interface{}, error) {
+	return nil, NewCodeError(40401, "商品不存在")
+}
+
+func TestWriteErrorHidesInternalErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := reflect.ValueOf(handlerTestController{})
+	cases := []struct {
+		method string
+		code   int
+		msg    string
+	}{
+		{"Internal", CodeFailed, "服务器内部错误"},
+		{"Coded", 40401, "商品不存在"},
+	}
+	for _, tc := range cases {
+		h, err := BuildHandler(ctrl.MethodByName(tc.method))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		h(c)
+		var resp APIResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Code != tc.code || resp.Msg != tc.msg {
+			t.Fatalf("%s: code=%d msg=%q, want %d %q", tc.method, resp.Code, resp.Msg, tc.code, tc.msg)
+		}
+	}
+}
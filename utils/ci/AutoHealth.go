@@ -0,0 +1,139 @@
+package ci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheckFn 健康检查函数，返回 nil 表示正常
+//
+// 示例：
+//
+//	func init() {
+//	    ci.BinHealthCheck("redis", func(ctx context.Context) error {
+//	        return rdb.Ping(ctx).Err()
+//	    })
+//	}
+type HealthCheckFn func(ctx context.Context) error
+
+// HealthCheckResult 单项检查结果
+type HealthCheckResult struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"` // ok / fail
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthReport 检查报告
+type HealthReport struct {
+	Status string              `json:"status"` // ok / fail
+	Reason string              `json:"reason,omitempty"`
+	Checks []HealthCheckResult `json:"checks"`
+}
+
+var (
+	healthChecks   = make(map[string]HealthCheckFn)
+	healthChecksMu sync.RWMutex
+
+	// notReadyReason 非空时 readyz 直接返回未就绪（如迁移中、停机中）
+	notReadyReason atomic.Value
+)
+
+func init() {
+	notReadyReason.Store("")
+	// 内置数据库连通性检查
+	BinHealthCheck("db", func(ctx context.Context) error {
+		db := D()
+		if db == nil {
+			return errors.New("数据库未初始化")
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+// BinHealthCheck 注册就绪检查项（同名覆盖），在 /readyz 中执行
+func BinHealthCheck(name string, fn HealthCheckFn) {
+	if name == "" || fn == nil {
+		return
+	}
+	healthChecksMu.Lock()
+	healthChecks[name] = fn
+	healthChecksMu.Unlock()
+}
+
+// SetReady 设置服务就绪状态；ready=false 时需说明原因，如 "migrating"、"shutting down"
+func SetReady(ready bool, reason string) {
+	if ready {
+		notReadyReason.Store("")
+		return
+	}
+	if reason == "" {
+		reason = "not ready"
+	}
+	notReadyReason.Store(reason)
+}
+
+// RunHealthChecks 并发执行所有检查项，每项超时由 ctx 控制，结果按名称排序
+func RunHealthChecks(ctx context.Context) HealthReport {
+	healthChecksMu.RLock()
+	names := make([]string, 0, len(healthChecks))
+	for name := range healthChecks {
+		names = append(names, name)
+	}
+	fns := make(map[string]HealthCheckFn, len(healthChecks))
+	for k, v := range healthChecks {
+		fns[k] = v
+	}
+	healthChecksMu.RUnlock()
+	sort.Strings(names)
+
+	results := make([]HealthCheckResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i] = runHealthCheck(ctx, name, fns[name])
+		}(i, name)
+	}
+	wg.Wait()
+
+	report := HealthReport{Status: "ok", Checks: results}
+	if reason, _ := notReadyReason.Load().(string); reason != "" {
+		report.Status = "fail"
+		report.Reason = reason
+	}
+	for _, r := range results {
+		if r.Status != "ok" {
+			report.Status = "fail"
+		}
+	}
+	return report
+}
+
+// runHealthCheck 执行单项检查并记录耗时，panic 视为失败
+func runHealthCheck(ctx context.Context, name string, fn HealthCheckFn) (result HealthCheckResult) {
+	start := time.Now()
+	result = HealthCheckResult{Name: name, Status: "ok"}
+	defer func() {
+		if r := recover(); r != nil {
+			result.Status = "fail"
+			result.Error = fmt.Sprintf("panic: %v", r)
+		}
+		result.LatencyMs = time.Since(start).Milliseconds()
+	}()
+	if err := fn(ctx); err != nil {
+		result.Status = "fail"
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,213 @@
+package ci
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// InjectTag 依赖注入字段标签
+//
+// 控制器（或服务）的导出字段标注 inject 后，在构建路由时自动注入 Provide 系列或 RegisterServer / BinService 注册的服务：
+//
+//	type ChatController struct {
+//	    Chat  *services.ChatService `inject:""`      // 按类型匹配
+//	    Store StoreService          `inject:""`      // 接口类型：匹配实现该接口的唯一服务
+//	    Mail  *services.MailService `inject:"mail"`  // 按名称匹配：mail / MailService / services.MailService
+//	}
+//
+// 未找到或匹配到多个服务时拒绝启动；控制器需以指针注册（ci.Register(&ChatController{}, ...)）。
+const InjectTag = "inject"
+
+// injectCandidate 可注入的服务：来自 Provide 系列与 RegisterServer / BinService
+type injectCandidate struct {
+	names    []string
+	provider *provider
+}
+
+// injectCandidates 收集所有已注册的服务，同一实例以不同类型注册时只保留一项
+func injectCandidates() []injectCandidate {
+	list := make([]injectCandidate, 0)
+	seen := make(map[interface{}]int)
+	ps := providersList()
+	// 按类型名排序，保证错误信息稳定
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].typ.String() == ps[j].typ.String() {
+			return ps[i].name < ps[j].name
+		}
+		return ps[i].typ.String() < ps[j].typ.String()
+	})
+	for _, p := range ps {
+		c := injectCandidate{names: []string{p.name, p.typ.String()}, provider: p}
+		if p.factory == nil && p.value.IsValid() && !isNilValue(p.value) {
+			key := p.value.Interface()
+			if reflect.TypeOf(key).Comparable() {
+				if i, ok := seen[key]; ok {
+					list[i].names = append(list[i].names, c.names...)
+					continue
+				}
+				seen[key] = len(list)
+			}
+		}
+		list = append(list, c)
+	}
+	return list
+}
+
+// matchServiceName 判断名称是否匹配（不区分大小写，可省略包名、* 与 Service 后缀）
+func matchServiceName(names []string, name string) bool {
+	for _, n := range names {
+		if n == "" {
+			continue
+		}
+		n = strings.TrimPrefix(n, "*")
+		short := n
+		if i := strings.LastIndex(short, "."); i >= 0 {
+			short = short[i+1:]
+		}
+		if strings.EqualFold(n, name) || strings.EqualFold(short, name) || strings.EqualFold(strings.TrimSuffix(short, "Service"), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Inject 为 target 中标注 inject 的字段注入服务，target 必须为结构体指针
+func Inject(target interface{}) error {
+	return injectWith(target, injectCandidates(), nil)
+}
+
+// InjectAll 依次为所有已注册的服务与 targets 注入依赖，返回全部错误
+func InjectAll(targets ...interface{}) error {
+	candidates := injectCandidates()
+	var errs []error
+	// 懒加载服务在创建时注入，此处仅处理已创建的实例
+	for _, c := range candidates {
+		if c.provider.factory != nil || !c.provider.value.IsValid() {
+			continue
+		}
+		if err := injectWith(c.provider.value.Interface(), candidates, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, t := range targets {
+		if err := injectWith(t, candidates, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// injectWith 为 target 注入依赖，path 为正在创建的懒加载服务链（见 provider.resolve）
+func injectWith(target interface{}, candidates []injectCandidate, path []*provider) error {
+	if target == nil {
+		return nil
+	}
+	v := reflect.ValueOf(target)
+	t := v.Type()
+	if !hasInjectFields(t) {
+		return nil
+	}
+	name := RemoveStarFromTypeName(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("%s 含 inject 字段，需以指针注册才能注入依赖", name)
+	}
+	elem := v.Elem()
+	var errs []error
+	for i := 0; i < elem.NumField(); i++ {
+		field := elem.Type().Field(i)
+		tag, ok := field.Tag.Lookup(InjectTag)
+		if !ok {
+			continue
+		}
+		if !field.IsExported() {
+			errs = append(errs, fmt.Errorf("%s.%s: inject 字段必须导出", name, field.Name))
+			continue
+		}
+		dep, err := resolveDependency(field.Type, strings.TrimSpace(tag), candidates, path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s.%s: %w", name, field.Name, err))
+			continue
+		}
+		elem.Field(i).Set(dep)
+	}
+	return errors.Join(errs...)
+}
+
+// hasInjectFields 判断类型（或其指向的结构体）是否包含 inject 字段
+func hasInjectFields(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup(InjectTag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveDependency 按名称或类型查找可赋值给 fieldType 的唯一服务
+func resolveDependency(fieldType reflect.Type, name string, candidates []injectCandidate, path []*provider) (reflect.Value, error) {
+	var matched []injectCandidate
+	for _, c := range candidates {
+		if name != "" && !matchServiceName(c.names, name) {
+			continue
+		}
+		if c.assignableTo(fieldType) {
+			matched = append(matched, c)
+		}
+	}
+	desc := fieldType.String()
+	if name != "" {
+		desc = fmt.Sprintf("%s（名称 %q）", desc, name)
+	}
+	switch len(matched) {
+	case 0:
+		return reflect.Value{}, fmt.Errorf("未找到可注入的服务 %s", desc)
+	case 1:
+		v, err := matched[0].provider.resolve(path)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return convertDependency(v, fieldType), nil
+	default:
+		names := make([]string, 0, len(matched))
+		for _, c := range matched {
+			names = append(names, c.provider.typ.String())
+		}
+		return reflect.Value{}, fmt.Errorf("服务 %s 存在多个候选: %s，请在 inject 标签中指定名称", desc, strings.Join(names, ", "))
+	}
+}
+
+// assignableTo 判断服务能否赋值给字段：按注册类型判断，已创建的实例同时按实际类型（及其指向的值）判断
+func (c injectCandidate) assignableTo(fieldType reflect.Type) bool {
+	p := c.provider
+	if p.typ.AssignableTo(fieldType) {
+		return true
+	}
+	if p.factory != nil || !p.value.IsValid() || isNilValue(p.value) {
+		return false
+	}
+	v := reflect.ValueOf(p.value.Interface())
+	if v.Type().AssignableTo(fieldType) {
+		return true
+	}
+	return v.Kind() == reflect.Ptr && v.Elem().Type().AssignableTo(fieldType)
+}
+
+// convertDependency 将服务实例转换为字段类型（必要时取指针指向的值）
+func convertDependency(v reflect.Value, fieldType reflect.Type) reflect.Value {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Type().AssignableTo(fieldType) {
+		return v
+	}
+	return v.Elem()
+}
@@ -0,0 +1,92 @@
+package ci
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// LifecycleHookFn 生命周期钩子。
+// OnStart 钩子返回错误时终止启动；OnShutdown 钩子的错误只记录日志，不影响其它钩子执行。
+//
+// 示例：
+//
+//	func init() {
+//	    ci.BinOnStart(func(ctx context.Context) error { return cache.Connect() })
+//	    ci.BinOnShutdown(func(ctx context.Context) error { return cache.Flush(ctx) })
+//	}
+type LifecycleHookFn func(ctx context.Context) error
+
+var (
+	onStartHooks    []LifecycleHookFn
+	onShutdownHooks []LifecycleHookFn
+)
+
+// BinOnStart 注册启动钩子（可多次调用追加），在路由初始化完成、开始监听端口之前按注册顺序执行。
+func BinOnStart(fn LifecycleHookFn) {
+	if fn == nil {
+		return
+	}
+	onStartHooks = append(onStartHooks, fn)
+}
+
+// BinOnShutdown 注册停机钩子（可多次调用追加），在 HTTP 服务排空、异步任务结束之后按注册的逆序执行，
+// 保证后注册（依赖别人）的插件先释放资源。
+func BinOnShutdown(fn LifecycleHookFn) {
+	if fn == nil {
+		return
+	}
+	onShutdownHooks = append(onShutdownHooks, fn)
+}
+
+// GetOnStartHooks 获取所有已注册的启动钩子
+func GetOnStartHooks() []LifecycleHookFn {
+	return onStartHooks
+}
+
+// GetOnShutdownHooks 获取所有已注册的停机钩子
+func GetOnShutdownHooks() []LifecycleHookFn {
+	return onShutdownHooks
+}
+
+// RunOnStartHooks 按注册顺序执行启动钩子，遇到第一个错误即返回
+func RunOnStartHooks(ctx context.Context) error {
+	for i, fn := range onStartHooks {
+		if err := fn(ctx); err != nil {
+			return fmt.Errorf("启动钩子[%d]执行失败: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// RunOnShutdownHooks 按注册逆序执行停机钩子，返回执行过程中遇到的第一个错误
+func RunOnShutdownHooks(ctx context.Context) error {
+	var firstErr error
+	for i := len(onShutdownHooks) - 1; i >= 0; i-- {
+		if err := onShutdownHooks[i](ctx); err != nil {
+			fmt.Printf("[shutdown] 停机钩子[%d]执行失败: %v\n", i, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// ShutdownTimeout 返回停机排空超时时间，取 config app.shutdown_timeout（秒），默认 15 秒
+func ShutdownTimeout() time.Duration {
+	if sec, err := strconv.Atoi(C("app.shutdown_timeout")); err == nil && sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return 15 * time.Second
+}
+
+// ShutdownDelay 返回停机前的摘流等待时间，取 config app.shutdown_delay（秒），默认 5 秒，0 为不等待。
+// 期间 /readyz 已返回 503 而监听仍在处理请求，供负载均衡 / 就绪探针摘除实例。
+func ShutdownDelay() time.Duration {
+	if sec, err := strconv.Atoi(C("app.shutdown_delay")); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return 5 * time.Second
+}
@@ -1,7 +1,13 @@
 package ci
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
 )
 
 var middlewares map[string]interface{}
@@ -31,3 +37,102 @@ func RegisterMiddlewares(value interface{}) bool {
 func GetMiddlewares() map[string]interface{} {
 	return middlewares
 }
+
+// MiddlewareFactory 具名中间件工厂，args 为配置中的参数（如 throttle:10/s 中的 10/s）
+type MiddlewareFactory func(args ...string) (gin.HandlerFunc, error)
+
+var (
+	namedMiddlewares   = make(map[string]MiddlewareFactory)
+	namedMiddlewaresMu sync.RWMutex
+)
+
+// RegisterMiddleware 注册具名中间件（同名覆盖），注册后可通过名称引用：
+//
+//	[middleware] 配置：global / api / agent / ws / plugin = name[:参数[:参数]]，多个用逗号分隔
+//	单个 action：   ci.RouteMeta{Use: []string{"throttle:5/m"}}
+//
+// 示例：
+//
+//	func init() {
+//	    ci.RegisterMiddleware("audit", func(args ...string) (gin.HandlerFunc, error) {
+//	        return func(c *gin.Context) { ... }, nil
+//	    })
+//	}
+func RegisterMiddleware(name string, factory MiddlewareFactory) {
+	if name == "" || factory == nil {
+		return
+	}
+	namedMiddlewaresMu.Lock()
+	namedMiddlewares[name] = factory
+	namedMiddlewaresMu.Unlock()
+}
+
+// HasMiddleware 判断具名中间件是否已注册
+func HasMiddleware(name string) bool {
+	namedMiddlewaresMu.RLock()
+	defer namedMiddlewaresMu.RUnlock()
+	_, ok := namedMiddlewares[name]
+	return ok
+}
+
+// GetMiddlewareNames 获取所有已注册的具名中间件名称（已排序）
+func GetMiddlewareNames() []string {
+	namedMiddlewaresMu.RLock()
+	names := make([]string, 0, len(namedMiddlewares))
+	for name := range namedMiddlewares {
+		names = append(names, name)
+	}
+	namedMiddlewaresMu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// ParseMiddlewareSpec 解析中间件引用：throttle:10/s → ("throttle", ["10/s"])
+func ParseMiddlewareSpec(spec string) (string, []string) {
+	parts := strings.Split(strings.TrimSpace(spec), ":")
+	name := strings.TrimSpace(parts[0])
+	var args []string
+	for _, arg := range parts[1:] {
+		args = append(args, strings.TrimSpace(arg))
+	}
+	return name, args
+}
+
+// BuildMiddleware 按引用创建具名中间件
+func BuildMiddleware(spec string) (gin.HandlerFunc, error) {
+	name, args := ParseMiddlewareSpec(spec)
+	namedMiddlewaresMu.RLock()
+	factory, ok := namedMiddlewares[name]
+	namedMiddlewaresMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("中间件 %q 未注册", name)
+	}
+	h, err := factory(args...)
+	if err != nil {
+		return nil, fmt.Errorf("中间件 %q: %w", spec, err)
+	}
+	if h == nil {
+		return nil, fmt.Errorf("中间件 %q 返回了空处理函数", spec)
+	}
+	return h, nil
+}
+
+// BuildMiddlewares 按引用列表依次创建具名中间件，返回处理函数与引用名称（用于路由清单）
+func BuildMiddlewares(specs []string) ([]gin.HandlerFunc, []string, error) {
+	var handlers []gin.HandlerFunc
+	var names []string
+	for _, spec := range specs {
+		h, err := BuildMiddleware(spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		handlers = append(handlers, h)
+		names = append(names, strings.TrimSpace(spec))
+	}
+	return handlers, names, nil
+}
+
+// GroupMiddlewareSpecs 读取 [middleware] 配置中指定分组的中间件引用：global / api / agent / ws / plugin
+func GroupMiddlewareSpecs(group string) []string {
+	return splitTrim(C("middleware." + group))
+}
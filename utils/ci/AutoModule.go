@@ -2,11 +2,13 @@ package ci
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"runtime"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -20,8 +22,14 @@ func init() {
 
 var _DB *gorm.DB
 
-// goroutineDBMap 按 goroutine ID 存储当前请求的带 tenant 的 DB
-var goroutineDBMap sync.Map
+// goroutineDBMap 按 goroutine ID 存储当前请求的带 tenant 的 DB；boundDBs 为已绑定数量，为 0 时 M() 不解析调用栈
+var (
+	goroutineDBMap sync.Map
+	boundDBs       atomic.Int64
+)
+
+// asyncWG 记录通过 ci.Go 等方法启动且尚未结束的异步任务，停机时等待其完成
+var asyncWG sync.WaitGroup
 
 // getGoroutineID 获取当前 goroutine ID
 func getGoroutineID() uint64 {
@@ -37,17 +45,35 @@ func getGoroutineID() uint64 {
 
 // BindDB 将带 tenant 的 DB 绑定到当前 goroutine，供 M() 自动获取。
 // 在中间件中调用，配合 defer UnbindDB() 使用。
+//
+// Deprecated: 按 goroutine ID 查找在 handler 启动新 goroutine 后失效，且每次调用都需解析调用栈；
+// 请使用 BindTenant 写入请求 context，并以 MC(ctx, model) 获取 DB。
 func BindDB(db *gorm.DB) {
-	goroutineDBMap.Store(getGoroutineID(), db)
+	if _, loaded := goroutineDBMap.Swap(getGoroutineID(), db); !loaded {
+		boundDBs.Add(1)
+	}
 }
 
 // UnbindDB 清除当前 goroutine 绑定的 DB，防止内存泄漏。
+//
+// Deprecated: 见 BindDB。
 func UnbindDB() {
-	goroutineDBMap.Delete(getGoroutineID())
+	if _, loaded := goroutineDBMap.LoadAndDelete(getGoroutineID()); loaded {
+		boundDBs.Add(-1)
+	}
+}
+
+// GoroutineDBEnabled 是否为已废弃的 M() 绑定 goroutine DB：默认开启，TenantVerify 与 Go / GoWithContext / GoWait
+// 每次均调用 BindDB（需解析调用栈）；代码全部改用 MC 后可配置 tenant.goroutine_db=false 关闭
+func GoroutineDBEnabled() bool {
+	return C("tenant.goroutine_db") != "false"
 }
 
 // currentDB 获取当前 goroutine 绑定的 DB，没有则返回全局 _DB
 func currentDB() *gorm.DB {
+	if boundDBs.Load() == 0 {
+		return _DB
+	}
 	if v, ok := goroutineDBMap.Load(getGoroutineID()); ok {
 		return v.(*gorm.DB)
 	}
@@ -65,11 +91,36 @@ func SetDB(db *gorm.DB) {
 	_DB = db
 }
 
-// D 获取全局数据库连接
+// D 获取全局数据库连接；tenant.mode 为 schema / database 时不含业务表，租户数据请使用 DBWithTenant / MC
 func D() *gorm.DB {
 	return _DB
 }
 
+// CloseDB 关闭全局数据库连接池与租户连接池（见 TenantStore），停机时在所有请求与异步任务结束后调用
+func CloseDB() error {
+	err := CloseTenantStores()
+	if _DB == nil {
+		return err
+	}
+	return errors.Join(err, closeGormDB(_DB))
+}
+
+// WaitAsync 等待通过 ci.Go / ci.GoWithContext / ci.GoWait / ci.Async 启动的异步任务全部结束。
+// ctx 超时或取消时提前返回 ctx.Err()。
+func WaitAsync(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		asyncWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // GetTenantID 从 Gin 上下文中获取当前请求的 tenant_id。
 // 在进入异步 goroutine 前调用此方法保存 tenantID，再在 goroutine 内用 TenantContext(tenantID) 做 DB 操作。
 func GetTenantID(c *gin.Context) string {
@@ -84,26 +135,34 @@ func GetTenantID(c *gin.Context) string {
 }
 
 // TenantContext 返回带有 tenant_id 的 context，用于异步/后台任务中的 DB 操作。
-// 用法：在 handler 里先 tenantID := ci.GetTenantID(c)，再在 goroutine 里 db := ci.D().WithContext(ci.TenantContext(tenantID))。
+// 用法：在 handler 里先 tenantID := ci.GetTenantID(c)，再在 goroutine 里 ci.MC(ci.TenantContext(tenantID), model)。
+// 返回的 context 携带请求作用域（见 Scope），也可直接用于 ci.MC(ctx, model)。
 func TenantContext(tenantID string) context.Context {
-	return context.WithValue(context.Background(), "tenant_id", tenantID)
+	return NewContext(context.Background(), &Scope{TenantID: tenantID})
 }
 
 // DBWithTenant 返回带有指定 tenant_id 的 DB 实例，用于异步方法中替代 GetDB(c)。
+// schema / database 模式下为该租户的独立连接。
 // 用法：go func() { db := ci.DBWithTenant(ci.GetTenantID(c)); ... }()
 func DBWithTenant(tenantID string) *gorm.DB {
-	return _DB.WithContext(TenantContext(tenantID))
+	return TenantDB(TenantContext(tenantID), tenantID)
 }
 
-// Go 启动一个带 tenant 上下文的 goroutine，自动传递 tenant_id。
-// goroutine 内 ci.M(m) 也能自动获取 tenant。
+// Go 启动一个带 tenant 上下文的 goroutine，自动传递 tenant_id 与 uid（见 DetachedContext）。
+// 未关闭 tenant.goroutine_db 时 goroutine 内 ci.M(m) 也能自动获取 tenant。
 // 用法：ci.Go(c, func(db *gorm.DB) { db.Create(&record) })
 func Go(c *gin.Context, fn func(db *gorm.DB)) {
-	tenantID := GetTenantID(c)
+	ctx := DetachedContext(c)
+	release := holdTenantStore(TenantIDFrom(ctx))
+	asyncWG.Add(1)
 	go func() {
-		db := DBWithTenant(tenantID)
-		BindDB(db)
-		defer UnbindDB()
+		defer asyncWG.Done()
+		defer release()
+		db := DBFrom(ctx)
+		if GoroutineDBEnabled() {
+			BindDB(db)
+			defer UnbindDB()
+		}
 		fn(db)
 	}()
 }
@@ -111,12 +170,17 @@ func Go(c *gin.Context, fn func(db *gorm.DB)) {
 // GoWithContext 启动一个带 tenant 上下文的 goroutine，同时传递 context 用于取消控制。
 // 用法：ci.GoWithContext(c, func(ctx context.Context, db *gorm.DB) { ... })
 func GoWithContext(c *gin.Context, fn func(ctx context.Context, db *gorm.DB)) {
-	tenantID := GetTenantID(c)
+	ctx := DetachedContext(c)
+	release := holdTenantStore(TenantIDFrom(ctx))
+	asyncWG.Add(1)
 	go func() {
-		ctx := TenantContext(tenantID)
-		db := _DB.WithContext(ctx)
-		BindDB(db)
-		defer UnbindDB()
+		defer asyncWG.Done()
+		defer release()
+		db := DBFrom(ctx)
+		if GoroutineDBEnabled() {
+			BindDB(db)
+			defer UnbindDB()
+		}
 		fn(ctx, db)
 	}()
 }
@@ -124,17 +188,32 @@ func GoWithContext(c *gin.Context, fn func(ctx context.Context, db *gorm.DB)) {
 // GoWait 启动一个带 tenant 上下文的 goroutine，并等待执行完成。
 // 用法：err := ci.GoWait(c, func(db *gorm.DB) error { return db.Create(&record).Error })
 func GoWait(c *gin.Context, fn func(db *gorm.DB) error) error {
-	tenantID := GetTenantID(c)
+	ctx := DetachedContext(c)
+	release := holdTenantStore(TenantIDFrom(ctx))
 	errCh := make(chan error, 1)
+	asyncWG.Add(1)
 	go func() {
-		db := DBWithTenant(tenantID)
-		BindDB(db)
-		defer UnbindDB()
+		defer asyncWG.Done()
+		defer release()
+		db := DBFrom(ctx)
+		if GoroutineDBEnabled() {
+			BindDB(db)
+			defer UnbindDB()
+		}
 		errCh <- fn(db)
 	}()
 	return <-errCh
 }
 
+// holdTenantStore 异步任务启动前持有租户连接（见 AcquireTenantStore），任务结束后释放；打开失败时由任务内的 DB 返回错误
+func holdTenantStore(tenantID string) func() {
+	if tenantID == "" {
+		return func() {}
+	}
+	_, release, _ := AcquireTenantStore(tenantID)
+	return release
+}
+
 // Run 在当前 goroutine 中使用带 tenant 的 DB 执行操作（非异步，用于统一写法）。
 // 用法：ci.Run(c, func(db *gorm.DB) { db.Find(&list) })
 func Run(c *gin.Context, fn func(db *gorm.DB)) {
@@ -160,16 +239,20 @@ func NewAsync(c *gin.Context) *Async {
 
 // WithContext 设置自定义 context（用于超时/取消控制）
 func (a *Async) WithContext(ctx context.Context) *Async {
-	a.ctx = context.WithValue(ctx, "tenant_id", a.tenantID)
+	a.ctx = NewContext(ctx, &Scope{TenantID: a.tenantID})
 	return a
 }
 
 // Go 启动异步任务
 func (a *Async) Go(fn func(db *gorm.DB)) {
+	release := holdTenantStore(a.tenantID)
+	asyncWG.Add(1)
 	go func() {
+		defer asyncWG.Done()
+		defer release()
 		var db *gorm.DB
 		if a.ctx != nil {
-			db = _DB.WithContext(a.ctx)
+			db = DBFrom(a.ctx)
 		} else {
 			db = DBWithTenant(a.tenantID)
 		}
@@ -179,11 +262,15 @@ func (a *Async) Go(fn func(db *gorm.DB)) {
 
 // Wait 启动异步任务并等待完成
 func (a *Async) Wait(fn func(db *gorm.DB) error) error {
+	release := holdTenantStore(a.tenantID)
 	errCh := make(chan error, 1)
+	asyncWG.Add(1)
 	go func() {
+		defer asyncWG.Done()
+		defer release()
 		var db *gorm.DB
 		if a.ctx != nil {
-			db = _DB.WithContext(a.ctx)
+			db = DBFrom(a.ctx)
 		} else {
 			db = DBWithTenant(a.tenantID)
 		}
@@ -195,7 +282,7 @@ func (a *Async) Wait(fn func(db *gorm.DB) error) error {
 // DB 获取带 tenant 的 DB 实例（用于手动控制 goroutine）
 func (a *Async) DB() *gorm.DB {
 	if a.ctx != nil {
-		return _DB.WithContext(a.ctx)
+		return DBFrom(a.ctx)
 	}
 	return DBWithTenant(a.tenantID)
 }
@@ -278,9 +365,10 @@ func findModule(name string) interface{} {
 	return nil
 }
 
-// M 创建 DB 实例，自动获取当前请求的租户上下文。
-// 在 HTTP 请求中无需传任何额外参数，中间件已自动绑定。
+// M 创建 DB 实例，获取当前 goroutine 绑定的租户 DB（由 TenantVerify 绑定，tenant.goroutine_db=false 时不绑定），未绑定时为全局连接。
 // 用法：ci.M(m).Where("id = ?", 1).First(&result)
+//
+// Deprecated: 依赖 goroutine 绑定（见 BindDB），在 handler 启动的 goroutine 中拿不到租户；请使用 MC(c, model) 或 MC(ctx, model)。
 func M(model interface{}) *DB {
 	return newDB(model, currentDB())
 }
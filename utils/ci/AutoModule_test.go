@@ -0,0 +1,47 @@
+package ci
+
+import (
+	"context"
+	"testing"
+)
+
+// BenchmarkM 已废弃的 M()：按 goroutine ID（解析调用栈）查找绑定的租户 DB
+func BenchmarkM(b *testing.B) {
+	db := newPluginDB(b)
+	BindDB(tenantDB(db, "t1"))
+	defer UnbindDB()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = M(&pluginOrder{})
+	}
+}
+
+// BenchmarkMC MC()：从 context 的请求作用域读取租户 DB
+func BenchmarkMC(b *testing.B) {
+	db := newPluginDB(b)
+	ctx := NewContext(context.Background(), &Scope{TenantID: "t1", DB: tenantDB(db, "t1")})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = MC(ctx, &pluginOrder{})
+	}
+}
+
+func TestMUnbound(t *testing.T) {
+	db := newPluginDB(t)
+	SetDB(db)
+	defer SetDB(nil)
+	if got := M(&pluginOrder{}); got.DB == nil {
+		t.Fatal("未绑定时 M() 应返回全局连接")
+	}
+	BindDB(tenantDB(db, "t1"))
+	var list []pluginOrder
+	if err := M(&pluginOrder{}).Find(&list).Error; err != nil || len(list) != 1 {
+		t.Fatalf("绑定后 M().Find = %d, %v, want 1", len(list), err)
+	}
+	UnbindDB()
+	if n := boundDBs.Load(); n != 0 {
+		t.Fatalf("UnbindDB 后 boundDBs = %d, want 0", n)
+	}
+}
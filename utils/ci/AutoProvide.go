@@ -0,0 +1,246 @@
+package ci
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// provider 类型化服务注册项
+type provider struct {
+	typ      reflect.Type                // 注册类型 T
+	name     string                      // 名称，默认实例为空
+	value    reflect.Value               // 实例（懒加载服务在首次使用后才有值）
+	factory  func() (interface{}, error) // 懒加载工厂
+	mu       sync.Mutex
+	creating chan struct{} // 懒加载服务创建中时非 nil，创建结束后关闭
+	err      error
+	resolved bool
+}
+
+// providerKey 注册表键：类型 + 名称
+type providerKey struct {
+	typ  reflect.Type
+	name string
+}
+
+var (
+	providers   = make(map[providerKey]*provider)
+	providersMu sync.RWMutex
+)
+
+// ServiceInfo 服务注册信息，见 ServiceReport
+type ServiceInfo struct {
+	Type     string `json:"type"`           // 注册类型
+	Name     string `json:"name,omitempty"` // 名称，默认实例为空
+	Impl     string `json:"impl,omitempty"` // 实现类型，懒加载服务未初始化时为空
+	Lazy     bool   `json:"lazy"`           // 是否懒加载
+	Resolved bool   `json:"resolved"`       // 是否已创建实例
+	Error    string `json:"error,omitempty"`
+}
+
+// Provide 以类型 T 注册服务实例（同类型重复注册时覆盖），通过 Use[T]() 获取：
+//
+//	func init() {
+//	    ci.Provide[*ChatService](&ChatService{})
+//	    ci.Provide[Storage](oss.New()) // 以接口类型注册
+//	}
+//
+//	chat := ci.Use[*ChatService]()
+func Provide[T any](impl T) {
+	ProvideNamed[T]("", impl)
+}
+
+// ProvideNamed 以类型 T + 名称注册服务实例，用于同一类型的多个实现，通过 UseNamed[T](name) 获取
+func ProvideNamed[T any](name string, impl T) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	setProvider(&provider{typ: typ, name: name, value: reflect.ValueOf(&impl).Elem(), resolved: true})
+}
+
+// ProvideLazy 注册懒加载单例：首次 Use 或注入时调用 factory 创建，之后复用同一实例；
+// 懒加载服务的 inject 字段之间存在循环依赖时，创建返回错误
+//
+//	ci.ProvideLazy(func() (*SmsClient, error) { return sms.NewClient(ci.C("sms.key")) })
+func ProvideLazy[T any](factory func() (T, error)) {
+	ProvideLazyNamed[T]("", factory)
+}
+
+// ProvideLazyNamed 以名称注册懒加载单例
+func ProvideLazyNamed[T any](name string, factory func() (T, error)) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	setProvider(&provider{typ: typ, name: name, factory: func() (interface{}, error) {
+		return factory()
+	}})
+}
+
+func setProvider(p *provider) {
+	providersMu.Lock()
+	providers[providerKey{typ: p.typ, name: p.name}] = p
+	providersMu.Unlock()
+}
+
+// Use 获取类型 T 的默认服务实例，未注册或懒加载创建失败时 panic（应在启动阶段确保已注册）
+func Use[T any]() T {
+	v, err := Lookup[T]("")
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// UseNamed 获取类型 T 的指定名称服务实例，未注册时 panic
+func UseNamed[T any](name string) T {
+	v, err := Lookup[T](name)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Lookup 获取类型 T 的服务实例（name 为空时为默认实例），未注册或创建失败时返回错误
+func Lookup[T any](name string) (T, error) {
+	var zero T
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	providersMu.RLock()
+	p, ok := providers[providerKey{typ: typ, name: name}]
+	providersMu.RUnlock()
+	if !ok {
+		if name == "" {
+			return zero, fmt.Errorf("服务 %s 未注册", typ)
+		}
+		return zero, fmt.Errorf("服务 %s（名称 %q）未注册", typ, name)
+	}
+	v, err := p.get()
+	if err != nil {
+		return zero, err
+	}
+	return v.Interface().(T), nil
+}
+
+// get 返回服务实例，懒加载服务首次调用时创建并注入其 inject 字段
+func (p *provider) get() (reflect.Value, error) {
+	return p.resolve(nil)
+}
+
+// resolve 返回服务实例；path 为当前注入链上正在创建的懒加载服务，再次出现时返回循环依赖错误。
+// 其它协程正在创建同一服务时等待其完成，创建失败的结果同样会被缓存。
+func (p *provider) resolve(path []*provider) (reflect.Value, error) {
+	if p.factory == nil {
+		return p.value, nil
+	}
+	for i, q := range path {
+		if q == p {
+			return reflect.Value{}, cycleError(append(path[i:], p))
+		}
+	}
+	p.mu.Lock()
+	if creating := p.creating; creating != nil && !p.resolved && p.err == nil {
+		p.mu.Unlock()
+		<-creating
+		p.mu.Lock()
+	}
+	if p.resolved || p.err != nil {
+		defer p.mu.Unlock()
+		return p.value, p.err
+	}
+	p.creating = make(chan struct{})
+	p.mu.Unlock()
+
+	v, err := p.create(append(path, p))
+	p.mu.Lock()
+	if err != nil {
+		p.err = err
+	} else {
+		p.value, p.resolved = v, true
+	}
+	close(p.creating)
+	p.mu.Unlock()
+	return v, err
+}
+
+// create 调用工厂创建实例并注入依赖，path 含当前服务
+func (p *provider) create(path []*provider) (reflect.Value, error) {
+	impl, err := p.factory()
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("创建服务 %s 失败: %w", p.typ, err)
+	}
+	v := reflect.New(p.typ).Elem()
+	if impl != nil {
+		v.Set(reflect.ValueOf(impl))
+	}
+	if err := injectWith(v.Interface(), injectCandidates(), path); err != nil {
+		return reflect.Value{}, err
+	}
+	return v, nil
+}
+
+// cycleError 描述懒加载服务之间的循环依赖，如 *A -> *B -> *A
+func cycleError(path []*provider) error {
+	names := make([]string, 0, len(path))
+	for _, p := range path {
+		names = append(names, p.typ.String())
+	}
+	return fmt.Errorf("服务循环依赖: %s", strings.Join(names, " -> "))
+}
+
+// ServiceReport 返回所有已注册服务的信息（含 Provide 系列与 RegisterServer / BinService），按类型与名称排序
+func ServiceReport() []ServiceInfo {
+	providersMu.RLock()
+	list := make([]ServiceInfo, 0, len(providers))
+	for _, p := range providers {
+		p.mu.Lock()
+		info := ServiceInfo{Type: p.typ.String(), Name: p.name, Lazy: p.factory != nil, Resolved: p.resolved}
+		if p.resolved && p.value.IsValid() && !isNilValue(p.value) {
+			info.Impl = reflect.TypeOf(p.value.Interface()).String()
+		}
+		if p.err != nil {
+			info.Error = p.err.Error()
+		}
+		p.mu.Unlock()
+		list = append(list, info)
+	}
+	providersMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Type == list[j].Type {
+			return list[i].Name < list[j].Name
+		}
+		return list[i].Type < list[j].Type
+	})
+	return list
+}
+
+// providersList 返回注册表快照
+func providersList() []*provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	list := make([]*provider, 0, len(providers))
+	for _, p := range providers {
+		list = append(list, p)
+	}
+	return list
+}
+
+// lookupByName 按名称查找服务实例（供 Server 兼容调用），名称规则同 inject 标签
+func lookupByName(name string) (interface{}, bool) {
+	for _, p := range providersList() {
+		if !matchServiceName([]string{p.name, p.typ.String()}, name) {
+			continue
+		}
+		v, err := p.get()
+		if err != nil || !v.IsValid() {
+			continue
+		}
+		return v.Interface(), true
+	}
+	return nil, false
+}
+
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type lazyCycleA struct {
+	B *lazyCycleB `inject:""`
+}
+
+type lazyCycleB struct {
+	A *lazyCycleA `inject:""`
+}
+
+type lazyLeaf struct{}
+
+type lazyRoot struct {
+	Leaf *lazyLeaf `inject:""`
+}
+
+func TestProvideLazyCycle(t *testing.T) {
+	ProvideLazy(func() (*lazyCycleA, error) { return &lazyCycleA{}, nil })
+	ProvideLazy(func() (*lazyCycleB, error) { return &lazyCycleB{}, nil })
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := Lookup[*lazyCycleA]("")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "循环依赖") {
+			t.Fatalf("Lookup err = %v, want 循环依赖", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("懒加载服务循环依赖导致死锁")
+	}
+}
+
+func TestProvideLazyInject(t *testing.T) {
+	ProvideLazy(func() (*lazyLeaf, error) { return &lazyLeaf{}, nil })
+	ProvideLazy(func() (*lazyRoot, error) { return &lazyRoot{}, nil })
+
+	root, err := Lookup[*lazyRoot]("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Leaf == nil || root.Leaf != Use[*lazyLeaf]() {
+		t.Fatalf("Leaf = %p, want the lazy singleton", root.Leaf)
+	}
+}
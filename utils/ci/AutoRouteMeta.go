@@ -0,0 +1,197 @@
+package ci
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteMeta 单个 action 的路由元数据，用于覆盖按方法名推断的默认行为
+type RouteMeta struct {
+	Path        string            // 路径：相对路径（如 detail/:id）替换方法名段，以 / 开头则为完整路径（须位于 /api/ 下）
+	Methods     []string          // HTTP 方法，设置后不受 router.strict_method 影响
+	Public      bool              // 公开接口，跳过 JWT 校验（等同加入 whitelist.items）
+	RateLimit   string            // 限流规则，如 10/s、100/m、1000/h，按客户端 IP 计数
+	Middlewares []gin.HandlerFunc // 额外中间件，在 action 之前按顺序执行
+	Use         []string          // 具名中间件引用（见 RegisterMiddleware），如 throttle:5/m，在 Middlewares 之前执行
+	Skip        bool              // 不为该 action 注册路由
+	NoREST      bool              // 不注册约定的 RESTful 路由（见 RESTMethod）
+}
+
+// RouteMetaProvider 控制器可选实现的接口，key 为 action（方法名），
+// 未声明的 action 仍按约定自动注册；Routes 方法本身不会注册为路由。
+//
+// 示例：
+//
+//	func (con GoodsController) Routes() map[string]ci.RouteMeta {
+//	    return map[string]ci.RouteMeta{
+//	        "Detail": {Path: "detail/:id", Methods: []string{"GET"}, Public: true},
+//	        "Create": {RateLimit: "10/m", Middlewares: []gin.HandlerFunc{AuditLog}},
+//	        "Export": {Use: []string{"throttle:1/m"}},
+//	        "Debug":  {Skip: true},
+//	    }
+//	}
+type RouteMetaProvider interface {
+	Routes() map[string]RouteMeta
+}
+
+// ControllerRouteMeta 获取控制器声明的路由元数据，未实现 RouteMetaProvider 时返回 nil
+func ControllerRouteMeta(controller interface{}) map[string]RouteMeta {
+	if p, ok := controller.(RouteMetaProvider); ok {
+		return p.Routes()
+	}
+	return nil
+}
+
+// IsRouteMetaMethod 判断方法是否为 RouteMetaProvider 的 Routes 方法（不注册为路由）
+func IsRouteMetaMethod(controller interface{}, action string) bool {
+	_, ok := controller.(RouteMetaProvider)
+	return ok && action == "Routes"
+}
+
+// ResolvePath 计算 action 的路由路径：base 为控制器路径前缀（如 /api/shop/goods/），
+// def 为约定生成的默认路径
+func (m RouteMeta) ResolvePath(base, def string) string {
+	p := strings.TrimSpace(m.Path)
+	switch {
+	case p == "":
+		return def
+	case strings.HasPrefix(p, "/"):
+		return p
+	case strings.HasSuffix(base, "/"):
+		return base + strings.TrimPrefix(p, "./")
+	default:
+		return base + "/" + strings.TrimPrefix(p, "./")
+	}
+}
+
+// ResolveMethods 计算 action 实际注册的 HTTP 方法
+func (m RouteMeta) ResolveMethods(inferred []string) []string {
+	if len(m.Methods) == 0 {
+		return RouteMethods(inferred)
+	}
+	methods := make([]string, 0, len(m.Methods))
+	for _, method := range m.Methods {
+		methods = append(methods, strings.ToUpper(strings.TrimSpace(method)))
+	}
+	return methods
+}
+
+// routeGroupPrefix 自动路由所在的路由组（应用与插件控制器均注册到该组，见 Bind、common.BindSoftwareRoutes），组内路由经过 JWT 与租户校验
+const routeGroupPrefix = "/api"
+
+// Validate 校验元数据（路径、限流规则、HTTP 方法）；完整路径不在 /api/ 下时不经过 JWT 与租户校验，拒绝注册
+func (m RouteMeta) Validate() error {
+	if p := strings.TrimSpace(m.Path); strings.HasPrefix(p, "/") && !strings.HasPrefix(p, routeGroupPrefix+"/") {
+		return fmt.Errorf("路径 %q 须位于 %s/ 下", p, routeGroupPrefix)
+	}
+	if m.RateLimit != "" {
+		if _, _, err := ParseRateLimit(m.RateLimit); err != nil {
+			return err
+		}
+	}
+	for _, method := range m.Methods {
+		switch strings.ToUpper(strings.TrimSpace(method)) {
+		case "GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS":
+		default:
+			return fmt.Errorf("不支持的 HTTP 方法: %q", method)
+		}
+	}
+	return nil
+}
+
+// Handlers 构造 action 之前执行的处理链（限流 + 具名中间件 + 自定义中间件）及其名称（用于路由清单），
+// 每次调用创建独立的限流计数器；元数据需先通过 Validate 校验，具名中间件未注册时记录到 RouteError
+func (m RouteMeta) Handlers() ([]gin.HandlerFunc, []string) {
+	var handlers []gin.HandlerFunc
+	var names []string
+	if m.RateLimit != "" {
+		if limiter, err := RateLimit(m.RateLimit); err == nil {
+			handlers = append(handlers, limiter)
+			names = append(names, "RateLimit("+m.RateLimit+")")
+		}
+	}
+	for _, spec := range m.Use {
+		h, err := BuildMiddleware(spec)
+		if err != nil {
+			AddRouteError(err)
+			continue
+		}
+		handlers = append(handlers, h)
+		names = append(names, strings.TrimSpace(spec))
+	}
+	for _, h := range m.Middlewares {
+		if h == nil {
+			continue
+		}
+		handlers = append(handlers, h)
+		names = append(names, HandlerName(h))
+	}
+	return handlers, names
+}
+
+// HandlerName 返回处理函数的短名称，如 middleware.AuditLog
+func HandlerName(h gin.HandlerFunc) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(h).Pointer())
+	if fn == nil {
+		return "func"
+	}
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+var (
+	publicRoutes   = make(map[string]bool)
+	publicRoutesMu sync.RWMutex
+
+	routeErrors []error
+)
+
+// SetPublicRoute 标记路由为公开接口（跳过 JWT 校验），path 为注册时的完整路由（含 :id 等参数）
+func SetPublicRoute(method, path string) {
+	publicRoutesMu.Lock()
+	publicRoutes[method+" "+path] = true
+	publicRoutesMu.Unlock()
+}
+
+// IsPublicRoute 判断路由是否为公开接口，path 传 c.FullPath()
+func IsPublicRoute(method, path string) bool {
+	publicRoutesMu.RLock()
+	defer publicRoutesMu.RUnlock()
+	return publicRoutes[method+" "+path]
+}
+
+// AddRouteError 记录路由声明错误（如限流规则格式错误），在构建路由时统一返回
+func AddRouteError(err error) {
+	if err != nil {
+		routeErrors = append(routeErrors, err)
+	}
+}
+
+// RouteError 返回已记录的路由声明错误
+func RouteError() error {
+	return errors.Join(routeErrors...)
+}
+
+// CheckRouteMeta 校验控制器声明的路由元数据：action 必须存在且元数据合法，避免拼写错误被静默忽略
+func CheckRouteMeta(controller interface{}, meta map[string]RouteMeta) {
+	t := reflect.TypeOf(controller)
+	ctrlName := RemoveStarFromTypeName(controller)
+	for action, m := range meta {
+		if _, ok := t.MethodByName(action); !ok {
+			AddRouteError(fmt.Errorf("%s.Routes() 声明的 action %q 不存在", ctrlName, action))
+			continue
+		}
+		if err := m.Validate(); err != nil {
+			AddRouteError(fmt.Errorf("%s.Routes()[%q]: %w", ctrlName, action, err))
+		}
+	}
+}
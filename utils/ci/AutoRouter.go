@@ -4,7 +4,10 @@ package ci
 * 自动路由工具
  */
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -12,15 +15,49 @@ import (
 
 // Route 路由结构体
 type Route struct {
-	path       string         //url路径
-	httpMethod string         //http方法 get post
-	Method     reflect.Value  //方法路由
-	Args       []reflect.Type //参数类型
+	path        string          //url路径
+	httpMethods []string        //按方法名推断的http方法
+	Method      reflect.Value   //方法路由
+	Args        []reflect.Type  //参数类型
+	handler     string          //控制器类型.方法名，用于路由清单
+	meta        RouteMeta       //控制器 Routes() 声明的路由元数据
+	call        gin.HandlerFunc //按方法签名包装的处理函数（见 BuildHandler）
 }
 
 // Routes 路由集合
 var Routes []Route
 
+// controllers 已注册的控制器，构建路由时为其注入依赖（见 InjectTag）
+var controllers []interface{}
+
+// GetRegisteredControllers 获取通过 Register 注册的控制器
+func GetRegisteredControllers() []interface{} {
+	return controllers
+}
+
+// RouteOwner 路由归属信息，在注册路由时记录，用于生成路由清单
+type RouteOwner struct {
+	Handler     string       // 控制器类型.方法名
+	Plugin      string       // 所属插件，应用自身路由为空
+	Group       string       // 注册时所在路由组：api / app 等
+	Middlewares []string     // 注册时生效的中间件链（不含 gin 全局中间件）
+	Request     reflect.Type // typed action 的请求参数类型（见 BuildHandler），用于生成 OpenAPI 文档
+	Response    reflect.Type // typed action 的返回值类型
+}
+
+var routeOwners = make(map[string]RouteOwner)
+
+// SetRouteOwner 记录路由归属，method 为 HTTP 方法，path 为完整路径
+func SetRouteOwner(method, path string, owner RouteOwner) {
+	routeOwners[method+" "+path] = owner
+}
+
+// GetRouteOwner 获取路由归属
+func GetRouteOwner(method, path string) (RouteOwner, bool) {
+	owner, ok := routeOwners[method+" "+path]
+	return owner, ok
+}
+
 // Register 注册控制器
 func Register(controller interface{}, PkgPathStr string) bool {
 	//fmt.Printf("日志[PkgPathStr]：%v\n", PkgPathStr)
@@ -29,7 +66,7 @@ func Register(controller interface{}, PkgPathStr string) bool {
 	if vbf.NumMethod() == 0 {
 		return false
 	}
-	basePkg := "/api"
+	basePkg := routeGroupPrefix
 	rootPkg := ""
 
 	if strings.Contains(PkgPathStr, "/app") {
@@ -43,6 +80,11 @@ func Register(controller interface{}, PkgPathStr string) bool {
 	rootPkg = basePkg + rootPkg
 	//获取模型名称
 	module := GetControllerModuleName(controller)
+	ctrlName := RemoveStarFromTypeName(controller)
+	controllers = append(controllers, controller)
+	//控制器可通过 Routes() 覆盖单个 action 的路由
+	metas := ControllerRouteMeta(controller)
+	CheckRouteMeta(controller, metas)
 
 	v := reflect.ValueOf(controller)
 	// fmt.Println("遍历方法:")
@@ -51,27 +93,30 @@ func Register(controller interface{}, PkgPathStr string) bool {
 	for i := 0; i < v.NumMethod(); i++ {
 		method := v.Method(i)
 		action := v.Type().Method(i).Name
+		meta := metas[action]
+		if meta.Skip || IsRouteMetaMethod(controller, action) {
+			continue
+		}
 		//拼接路由地址
-		path := rootPkg + module + FirstLower(action)
+		path := meta.ResolvePath(rootPkg+module, rootPkg+module+FirstLower(action))
+		//按方法签名包装处理函数，不支持的签名（如辅助方法）跳过注册
+		call, err := BuildHandler(method)
+		if err != nil {
+			fmt.Printf("[router] 跳过 %s.%s: %v\n", ctrlName, action, err)
+			continue
+		}
 		//遍历参数
 		params := make([]reflect.Type, 0, v.NumMethod())
-		httpMethod := "POST" //默认POST
-		if (strings.HasPrefix(action, "Get") && !strings.HasPrefix(action, "GetPost")) || action == "Index" {
-			httpMethod = "GET"
-		} else if strings.HasPrefix(action, "Del") || action == "Del" {
-			httpMethod = "DELETE"
-		} else if strings.HasPrefix(action, "Put") || action == "Put" {
-			httpMethod = "PUT"
-		}
 		for j := 0; j < method.Type().NumIn(); j++ {
 			params = append(params, method.Type().In(j))
 		}
 		// fmt.Println("params=", params)
 		// fmt.Println("action=", action)
-		route := Route{path: path, Method: method, Args: params, httpMethod: httpMethod}
+		route := Route{path: path, Method: method, Args: params, httpMethods: InferHTTPMethods(action), handler: ctrlName + "." + action, meta: meta, call: call}
 		Routes = append(Routes, route)
-		if strings.HasPrefix(action, "GetPost") { //再增加一个get请求
-			route := Route{path: path, Method: method, Args: params, httpMethod: "GET"}
+		//RESTful 路由：Detail → GET /goods/:id，静态路由保持不变
+		if rest, ok := RESTRoute(rootPkg+module, action, meta); ok {
+			route.path, route.meta = rest.Path, rest
 			Routes = append(Routes, route)
 		}
 	}
@@ -79,11 +124,142 @@ func Register(controller interface{}, PkgPathStr string) bool {
 	return true
 }
 
-// Bind 绑定路由 m是方法GET POST等
-func Bind(e *gin.Engine) {
+// InferHTTPMethods 根据方法名推断 HTTP 方法：
+//
+//	Index、GetXxx → GET
+//	GetPostXxx    → GET + POST
+//	DelXxx        → DELETE
+//	PutXxx        → PUT
+//	其它          → POST
+func InferHTTPMethods(action string) []string {
+	switch {
+	case strings.HasPrefix(action, "GetPost"):
+		return []string{"GET", "POST"}
+	case action == "Index" || strings.HasPrefix(action, "Get"):
+		return []string{"GET"}
+	case strings.HasPrefix(action, "Del"):
+		return []string{"DELETE"}
+	case strings.HasPrefix(action, "Put"):
+		return []string{"PUT"}
+	default:
+		return []string{"POST"}
+	}
+}
+
+// RESTParams 是否为 Detail/Update/Delete 额外注册 RESTful 路由（config router.rest_params，默认 true）
+func RESTParams() bool {
+	return C("router.rest_params") != "false"
+}
+
+// RESTMethod 返回 action 约定的 RESTful 方法：
+//
+//	Detail → GET    /goods/:id
+//	Update → PUT    /goods/:id
+//	Delete → DELETE /goods/:id
+func RESTMethod(action string) (string, bool) {
+	switch action {
+	case "Detail":
+		return "GET", true
+	case "Update":
+		return "PUT", true
+	case "Delete":
+		return "DELETE", true
+	}
+	return "", false
+}
+
+// RESTRoute 根据约定生成 action 的 RESTful 路由元数据，base 为控制器路径前缀（如 /api/shop/goods/）；
+// 返回的元数据继承 meta 的鉴权、限流与中间件设置，Path 为完整路径、Methods 固定为约定方法
+func RESTRoute(base, action string, meta RouteMeta) (RouteMeta, bool) {
+	method, ok := RESTMethod(action)
+	if !ok || meta.NoREST || !RESTParams() {
+		return RouteMeta{}, false
+	}
+	rest := meta
+	rest.Path = strings.TrimSuffix(base, "/") + "/:id"
+	rest.Methods = []string{method}
+	return rest, true
+}
+
+// PathParamsToQuery 将路径参数（如 :id）写入查询参数（已存在的同名查询参数不覆盖），
+// 使通过 c.Query("id") 或 ShouldBindQuery 读取 ID 的 action 无需修改即可用于 RESTful 路由
+func PathParamsToQuery(c *gin.Context) {
+	if len(c.Params) == 0 {
+		return
+	}
+	query := c.Request.URL.Query()
+	for _, p := range c.Params {
+		if !query.Has(p.Key) {
+			query.Set(p.Key, p.Value)
+		}
+	}
+	c.Request.URL.RawQuery = query.Encode()
+}
+
+// ParamID 获取请求中的 ID：依次读取路径参数 :id、查询参数 id、表单参数 id
+func ParamID(c *gin.Context) (int64, error) {
+	id := c.Param("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+	if id == "" {
+		id = c.PostForm("id")
+	}
+	if id == "" {
+		return 0, errors.New("缺少参数 id")
+	}
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("参数 id 格式错误: %q", id)
+	}
+	return n, nil
+}
+
+// StrictMethod 是否严格按推断的 HTTP 方法注册路由（config router.strict_method，默认 false）
+func StrictMethod() bool {
+	return C("router.strict_method") == "true"
+}
+
+// RouteMethods 返回实际注册的 HTTP 方法：严格模式下仅为推断方法；
+// 兼容模式下在推断方法之外同时注册 GET 与 POST（旧版本行为）
+func RouteMethods(inferred []string) []string {
+	if StrictMethod() {
+		return inferred
+	}
+	methods := []string{"GET", "POST"}
+	for _, m := range inferred {
+		if m != "GET" && m != "POST" {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+// Bind 将应用控制器路由注册到 g（/api 路由组，经过 JWT、租户校验与 [middleware] api 中间件）；
+// chain 返回路由所在分组的中间件名称（用于路由清单），不在 g 下的路由不注册并记录到 RouteError
+func Bind(g *gin.RouterGroup, chain func(path string) []string) {
+	base := strings.TrimSuffix(g.BasePath(), "/")
 	for _, route := range Routes {
-		//只允许GET或者POST
-		e.Match([]string{"GET", "POST"}, route.path, match(route.path, route))
+		if !strings.HasPrefix(route.path, base+"/") {
+			AddRouteError(fmt.Errorf("路由 %s（%s）不在 %s/ 下，未注册", route.path, route.handler, base))
+			continue
+		}
+		methods := route.meta.ResolveMethods(route.httpMethods)
+		handlers, names := route.meta.Handlers()
+		if strings.Contains(route.path, "/:") {
+			handlers = append(handlers, PathParamsToQuery)
+		}
+		g.Match(methods, route.path[len(base):], append(handlers, match(route.path, route))...)
+		if chain != nil {
+			names = append(chain(route.path), names...)
+		}
+		for _, m := range methods {
+			req, resp := HandlerTypes(route.Method)
+			SetRouteOwner(m, route.path, RouteOwner{Handler: route.handler, Group: "app", Middlewares: names, Request: req, Response: resp})
+			if route.meta.Public {
+				SetPublicRoute(m, route.path)
+			}
+		}
 	}
 }
 
@@ -96,9 +272,7 @@ func match(path string, route Route) gin.HandlerFunc {
 			return
 		}
 		if len(Routes) > 0 {
-			arguments := make([]reflect.Value, 1)
-			arguments[0] = reflect.ValueOf(c) // *gin.Context
-			route.Method.Call(arguments)
+			route.call(c)
 		}
 	}
 }
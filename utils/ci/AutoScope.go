@@ -0,0 +1,139 @@
+package ci
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Scope 请求作用域：当前租户、登录用户与带租户上下文的 DB，随 context.Context 传递，
+// 由 JwtVerify / TenantVerify / WsVerify 写入请求的 context，goroutine 中传递 ctx 即可继续使用：
+//
+//	func (con OrderController) Create(c *gin.Context) {
+//	    ctx := c.Request.Context()
+//	    go func() {
+//	        ci.MC(ctx, &models.Order{}).Create(&order)
+//	    }()
+//	}
+type Scope struct {
+	TenantID string
+	UID      int64
+	DB       *gorm.DB // 租户 DB，为空时使用全局连接
+}
+
+// scopeKey context 中 Scope 的键
+type scopeKey struct{}
+
+// NewContext 返回携带 scope 的 context，租户通过 TenantIDFrom 读取
+func NewContext(ctx context.Context, s *Scope) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// ScopeFrom 从 context 中获取请求作用域，ctx 为 *gin.Context 时读取其请求的 context
+func ScopeFrom(ctx context.Context) (*Scope, bool) {
+	ctx = requestContext(ctx)
+	if ctx == nil {
+		return nil, false
+	}
+	s, ok := ctx.Value(scopeKey{}).(*Scope)
+	return s, ok && s != nil
+}
+
+// TenantIDFrom 从 context 中获取租户：优先请求作用域（见 NewContext、TenantContext），
+// 其次兼容旧代码以 context.WithValue(ctx, "tenant_id", id) 写入的值，均不存在时返回空
+func TenantIDFrom(ctx context.Context) string {
+	if s, ok := ScopeFrom(ctx); ok && s.TenantID != "" {
+		return s.TenantID
+	}
+	if ctx = requestContext(ctx); ctx != nil {
+		if id, ok := ctx.Value("tenant_id").(string); ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// requestContext *gin.Context 默认不回退到请求的 context，此处统一取 c.Request.Context()
+func requestContext(ctx context.Context) context.Context {
+	if c, ok := ctx.(*gin.Context); ok {
+		if c == nil || c.Request == nil {
+			return nil
+		}
+		return c.Request.Context()
+	}
+	return ctx
+}
+
+// RequestScope 返回当前请求的作用域，不存在时创建并写入请求的 context（供中间件逐步填充）
+func RequestScope(c *gin.Context) *Scope {
+	if s, ok := ScopeFrom(c); ok {
+		return s
+	}
+	s := &Scope{}
+	if c.Request != nil {
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), s))
+	}
+	return s
+}
+
+// BindTenant 设置当前请求的租户：写入请求作用域与 context，返回带租户上下文的 DB（中间件使用）；
+// schema / database 模式下为该租户的独立连接，由 AcquireTenantStore 持有直到调用 release（请求结束后），打开失败时返回错误
+//
+//	db, release, err := ci.BindTenant(c, tenantID)
+//	if err != nil { ... }
+//	defer release()
+func BindTenant(c *gin.Context, tenantID string) (db *gorm.DB, release func(), err error) {
+	release = func() {}
+	s := RequestScope(c)
+	s.TenantID = tenantID
+	c.Set("tenant_id", tenantID)
+	if _DB != nil {
+		store, storeRelease, err := AcquireTenantStore(tenantID)
+		if err != nil {
+			return nil, release, err
+		}
+		s.DB, release = store.WithContext(c.Request.Context()), storeRelease
+	}
+	c.Set("db", s.DB)
+	return s.DB, release, nil
+}
+
+// DetachedContext 返回脱离请求生命周期的 context，复制当前请求的租户与用户（请求结束后 c.Request.Context() 会被取消），
+// 用于 Go / GoWait 等异步任务
+func DetachedContext(c *gin.Context) context.Context {
+	s := &Scope{TenantID: GetTenantID(c)}
+	if rs, ok := ScopeFrom(c); ok {
+		s.UID = rs.UID
+	}
+	return NewContext(context.Background(), s)
+}
+
+// DBFrom 返回 context 对应的 DB：请求作用域中的租户 DB，其次为 context 中租户的 DB（见 TenantDB），否则为全局连接；均携带 ctx（租户、取消与超时）
+func DBFrom(ctx context.Context) *gorm.DB {
+	ctx = requestContext(ctx)
+	if s, ok := ScopeFrom(ctx); ok && s.DB != nil {
+		return s.DB.WithContext(ctx)
+	}
+	if ctx == nil {
+		return _DB
+	}
+	if tenantID := TenantIDFrom(ctx); tenantID != "" {
+		return TenantDB(ctx, tenantID)
+	}
+	if _DB == nil {
+		return nil
+	}
+	return _DB.WithContext(ctx)
+}
+
+// MC 按 context 创建 DB 实例，可在 handler 启动的 goroutine 中安全使用（替代依赖 goroutine 绑定的 M）：
+//
+//	ci.MC(c, &models.Expert{}).Where("id = ?", 1).First(&result)
+//	go func() { ci.MC(ctx, "expert").Find(&list) }()
+func MC(ctx context.Context, model interface{}) *DB {
+	return newDB(model, DBFrom(ctx))
+}
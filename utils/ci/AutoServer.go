@@ -26,9 +26,16 @@ func RegisterServer(module interface{}) bool {
 	fmt.Printf("[%s]这里是执行了RegisterServer 注册服务\n", cleanedName)
 	//存入Map列表
 	servers[cleanedName] = module
+	//同时以实例类型登记到类型化注册表，可通过 ci.Use[*XxxService]() 获取
+	provideInstance(module)
 	return true
 }
 
+// provideInstance 以实例的实际类型登记到类型化注册表
+func provideInstance(instance interface{}) {
+	setProvider(&provider{typ: reflect.TypeOf(instance), value: reflect.ValueOf(instance), resolved: true})
+}
+
 // GetServers 用于获取所有已注册的 modules
 func GetServers() map[string]interface{} {
 	return servers
@@ -40,6 +47,10 @@ func SetServers(name interface{}) {
 }
 
 // Server 用于调用服务
+//
+// Deprecated: 按字符串查找并反射调用，参数类型错误只能在运行时发现；
+// 请改用 ci.Use[*XxxService]() 获取类型化实例后直接调用方法。
+// 仍可查找 RegisterServer 与 Provide 系列注册的服务（名称规则同 inject 标签）。
 func Server(name, methodName string, args ...interface{}) (interface{}, error) {
 
 	// 将输入的 name 转换成首字母大写的格式相连
@@ -63,19 +74,21 @@ func Server(name, methodName string, args ...interface{}) (interface{}, error) {
 
 	// 获取 modules 中对应的模型切片
 	serverSlice, exists := servers[name]
+	if !exists {
+		serverSlice, exists = lookupByName(name)
+	}
 	if !exists {
 		errMsg := fmt.Sprintf("未找到对应的服务: %s", name)
 		fmt.Println(errMsg)
 		return nil, fmt.Errorf(errMsg)
 	}
 
-	// 使用反射调用方法
+	// 使用反射调用方法（先查找指针接收者方法，再查找值接收者方法）
 	value := reflect.ValueOf(serverSlice)
-	if value.Kind() == reflect.Ptr {
-		value = value.Elem()
-	}
-
 	method := value.MethodByName(methodName)
+	if !method.IsValid() && value.Kind() == reflect.Ptr {
+		method = value.Elem().MethodByName(methodName)
+	}
 	if method.IsValid() {
 		methodType := method.Type()
 		// 检查是否为可变参数方法
@@ -97,10 +110,32 @@ func Server(name, methodName string, args ...interface{}) (interface{}, error) {
 			}
 		}
 
-		// 将可变参数转换为 reflect.Value 切片
+		if !isVariadic && len(args) > numRequiredArgs {
+			return nil, fmt.Errorf("参数传递错误：方法 %s 需要 %d 个参数，实际传递了 %d 个", methodName, numRequiredArgs, len(args))
+		}
+
+		// 将可变参数转换为 reflect.Value 切片，类型不匹配时返回错误而非 panic
 		var reflectArgs []reflect.Value
-		for _, arg := range args {
-			reflectArgs = append(reflectArgs, reflect.ValueOf(arg))
+		for i, arg := range args {
+			var paramType reflect.Type
+			if isVariadic && i >= numRequiredArgs {
+				paramType = methodType.In(methodType.NumIn() - 1).Elem()
+			} else {
+				paramType = methodType.In(i)
+			}
+			argValue := reflect.ValueOf(arg)
+			if arg == nil {
+				switch paramType.Kind() {
+				case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+					argValue = reflect.Zero(paramType)
+				default:
+					return nil, fmt.Errorf("参数传递错误：方法 %s 第 %d 个参数需要 %s，实际为 nil", methodName, i+1, paramType)
+				}
+			}
+			if !argValue.Type().AssignableTo(paramType) {
+				return nil, fmt.Errorf("参数传递错误：方法 %s 第 %d 个参数需要 %s，实际为 %s", methodName, i+1, paramType, argValue.Type())
+			}
+			reflectArgs = append(reflectArgs, argValue)
 		}
 		// 调用方法
 		results := method.Call(reflectArgs)
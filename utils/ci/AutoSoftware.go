@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -12,9 +13,11 @@ import (
 var (
 	softwareApp         string
 	softwareMiddlewares map[string]interface{}
-	softwareControllers map[string]interface{}
-	softwareModules     map[string]interface{}
-	softwareServices    map[string]interface{}
+	// softwareMiddlewarePlugins 中间件注册时所属插件，用于 Scope() 返回 plugin 时的作用范围
+	softwareMiddlewarePlugins map[string]string
+	softwareControllers       map[string]interface{}
+	softwareModules           map[string]interface{}
+	softwareServices          map[string]interface{}
 )
 
 // WSHandlerFn WS 控制器注册回调
@@ -71,12 +74,15 @@ func init() {
 func SoftwareInit() {
 	softwareApp = ""
 	softwareMiddlewares = make(map[string]interface{})
+	softwareMiddlewarePlugins = make(map[string]string)
 	softwareControllers = make(map[string]interface{})
 	softwareModules = make(map[string]interface{})
 	softwareServices = make(map[string]interface{})
 	wsHandlers = nil
 	agentRoutesHandlers = nil
 	ginAfterRouterHooks = nil
+	onStartHooks = nil
+	onShutdownHooks = nil
 }
 
 // GetControllerPrefixRegex 使用正则表达式从路径中提取"controllers"前的元素
@@ -180,10 +186,19 @@ func BinService(service interface{}) bool {
 	//fmt.Printf("3.获得模型的路径%s", cleanedName)
 	// 存入 Map 列表
 	softwareServices[cleanedName] = service
+	// 同时登记到类型化注册表，可通过 ci.Use[*XxxService]() 获取
+	provideInstance(service)
 	return true
 }
 
 // BinMiddleware 绑定中间件（修正注释描述，原注释写的"绑定服务"）
+//
+// 中间件可选实现以下方法：
+//
+//	HandleBefore(c *gin.Context)  在 JWT/租户校验之前执行
+//	HandleAfter(c *gin.Context)   在 JWT/租户校验之后执行
+//	Priority() int                执行顺序，数值小的先执行，默认 0；相同时按类型名排序
+//	Scope() string                作用范围，见 MiddlewareScope
 func BinMiddleware(middleware interface{}) bool {
 	t := reflect.TypeOf(middleware)
 	var cleanedName string
@@ -196,15 +211,123 @@ func BinMiddleware(middleware interface{}) bool {
 	}
 	// 存入 Map 列表
 	softwareMiddlewares[cleanedName] = middleware
+	// 记录所属插件：优先取包路径中 middleware(s) 前的目录名，否则取当前插件名
+	plugin, err := getMiddlewarePrefixRegex(t.Elem().PkgPath())
+	if err != nil {
+		plugin = softwareApp
+	}
+	softwareMiddlewarePlugins[cleanedName] = plugin
 	return true
 }
 
-// GetMiddlewaresList 获取所有已注册的中间件（修正注释描述，原注释写的"获取所有已注册的服务"）
+// getMiddlewarePrefixRegex 从包路径中提取 middleware(s) 前的目录名，如 github.com/qinuoyun/shop/middleware → shop
+func getMiddlewarePrefixRegex(path string) (string, error) {
+	matches := regexp.MustCompile(`([^/]+)/middlewares?($|/)`).FindStringSubmatch(path)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("未找到符合模式的内容: %s", path)
+	}
+	return matches[1], nil
+}
+
+// MiddlewareScope 中间件作用范围，由中间件的 Scope() 方法声明：
+//
+//	"" 或 "global"      所在路由组的全部路由（默认）
+//	"plugin"            仅注册该中间件的插件自身的路由
+//	"plugin:shop"       仅 shop 插件的路由
+//	"prefix:/api/shop"  仅路径以 /api/shop 开头的路由
+type MiddlewareScope struct {
+	Kind  string // global / plugin / prefix
+	Value string // 插件名或路径前缀
+}
+
+// Global 是否作用于全部路由
+func (s MiddlewareScope) Global() bool {
+	return s.Kind == "" || s.Kind == "global"
+}
+
+// Applies 判断路由是否在作用范围内，plugin 为路由所属插件，path 为路由路径
+func (s MiddlewareScope) Applies(plugin, path string) bool {
+	switch s.Kind {
+	case "plugin":
+		return plugin != "" && plugin == s.Value
+	case "prefix":
+		return path == s.Value || strings.HasPrefix(path, strings.TrimSuffix(s.Value, "/")+"/")
+	default:
+		return true
+	}
+}
+
+func (s MiddlewareScope) String() string {
+	if s.Global() {
+		return "global"
+	}
+	return s.Kind + ":" + s.Value
+}
+
+// GetMiddlewareScope 解析中间件声明的作用范围，未实现 Scope() 时为 global
+func GetMiddlewareScope(middleware interface{}) (MiddlewareScope, error) {
+	m, ok := middleware.(interface{ Scope() string })
+	if !ok {
+		return MiddlewareScope{Kind: "global"}, nil
+	}
+	spec := strings.TrimSpace(m.Scope())
+	kind, value, _ := strings.Cut(spec, ":")
+	kind, value = strings.TrimSpace(kind), strings.TrimSpace(value)
+	name := RemoveStarFromTypeName(middleware)
+	switch kind {
+	case "", "global":
+		return MiddlewareScope{Kind: "global"}, nil
+	case "plugin":
+		if value == "" {
+			t := reflect.TypeOf(middleware)
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			value = softwareMiddlewarePlugins[t.Name()]
+		}
+		if value == "" {
+			return MiddlewareScope{}, fmt.Errorf("中间件 %s 的作用范围 plugin 无法确定所属插件，请使用 plugin:插件名", name)
+		}
+		return MiddlewareScope{Kind: "plugin", Value: value}, nil
+	case "prefix":
+		if !strings.HasPrefix(value, "/") {
+			return MiddlewareScope{}, fmt.Errorf("中间件 %s 的作用范围 %q 格式错误，应为 prefix:/路径前缀", name, spec)
+		}
+		return MiddlewareScope{Kind: "prefix", Value: value}, nil
+	default:
+		return MiddlewareScope{}, fmt.Errorf("中间件 %s 的作用范围 %q 不支持，仅支持 global / plugin / prefix", name, spec)
+	}
+}
+
+// GetMiddlewarePriority 获取中间件的执行优先级，未实现 Priority() 时为 0
+func GetMiddlewarePriority(middleware interface{}) int {
+	if m, ok := middleware.(interface{ Priority() int }); ok {
+		return m.Priority()
+	}
+	return 0
+}
+
+// GetMiddlewaresList 获取所有已注册的中间件（BinMiddleware 与 RegisterMiddlewares），
+// 按 Priority() 升序、类型名升序排列，保证每次启动顺序一致
 func GetMiddlewaresList() []interface{} {
 	var middlewares []interface{}
+	seen := make(map[string]bool)
 	for _, middleware := range softwareMiddlewares {
 		middlewares = append(middlewares, middleware)
+		seen[RemoveStarFromTypeName(middleware)] = true
+	}
+	for name, middleware := range GetMiddlewares() {
+		if !seen[name] {
+			middlewares = append(middlewares, middleware)
+		}
 	}
+	sort.SliceStable(middlewares, func(i, j int) bool {
+		pi, pj := GetMiddlewarePriority(middlewares[i]), GetMiddlewarePriority(middlewares[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return RemoveStarFromTypeName(middlewares[i]) < RemoveStarFromTypeName(middlewares[j])
+	})
 	return middlewares
 }
 
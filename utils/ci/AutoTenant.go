@@ -0,0 +1,305 @@
+package ci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 租户状态
+const (
+	TenantActive    = "active"    // 正常
+	TenantSuspended = "suspended" // 已停用：TenantVerify / WsVerify 拒绝访问
+)
+
+// 租户注册表错误，可用 errors.Is 判断
+var (
+	ErrTenantNotFound  = errors.New("租户不存在")
+	ErrTenantSuspended = errors.New("租户已停用")
+	ErrTenantExists    = errors.New("租户已存在")
+)
+
+// Tenant 租户注册表（tenant.registry=true 时迁移并由 TenantVerify / WsVerify 校验）。
+// 不嵌入 ci.Model，不受 TenantPlugin 隔离；删除为软删除，已删除的租户视为不存在且 tenant_id 不可复用。
+type Tenant struct {
+	ID            uint                   `gorm:"primaryKey" json:"id"`
+	TenantID      string                 `gorm:"type:varchar(32);not null;uniqueIndex;column:tenant_id" json:"tenant_id"`
+	Name          string                 `gorm:"type:varchar(100)" json:"name"`
+	Status        string                 `gorm:"type:varchar(16);not null;default:active;index" json:"status"`
+	Plan          string                 `gorm:"type:varchar(32)" json:"plan"`
+	Metadata      map[string]interface{} `gorm:"serializer:json;type:text" json:"metadata"`
+	SuspendReason string                 `gorm:"type:varchar(255)" json:"suspend_reason,omitempty"`
+	SuspendedAt   *time.Time             `json:"suspended_at,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt         `gorm:"index" json:"-"`
+}
+
+// Active 租户是否可正常访问
+func (t *Tenant) Active() bool {
+	return t.Status == TenantActive
+}
+
+// TenantUpdate 租户可修改的字段，nil 表示不修改；Metadata 整体替换
+type TenantUpdate struct {
+	Name     *string
+	Plan     *string
+	Metadata map[string]interface{}
+}
+
+// TenantService 租户注册表服务：创建、停用、恢复、删除与元数据维护，
+// Check 带缓存（tenant.registry_cache_ttl 秒，默认 60，0 为不缓存），本实例的修改立即失效缓存，其它实例在 TTL 后生效。
+type TenantService struct {
+	cache sync.Map // tenant_id -> tenantCacheEntry
+}
+
+// tenantCacheEntry 缓存项，tenant 为 nil 表示租户不存在
+type tenantCacheEntry struct {
+	tenant  *Tenant
+	expires time.Time
+}
+
+var tenantService = &TenantService{}
+
+func init() {
+	Provide[*TenantService](tenantService)
+}
+
+// Tenants 返回租户注册表服务（也可通过 ci.Use[*ci.TenantService]() 或注入获取）
+//
+//	t, err := ci.Tenants().Create(ctx, &ci.Tenant{TenantID: "t1", Name: "示例租户", Plan: "pro"})
+//	err = ci.Tenants().Suspend(ctx, "t1", "欠费")
+func Tenants() *TenantService {
+	return tenantService
+}
+
+// TenantRegistryEnabled 是否启用租户注册表（tenant.registry=true）
+func TenantRegistryEnabled() bool {
+	return C("tenant.registry") == "true"
+}
+
+// MigrateTenants 迁移租户表，并登记 app.tenant_id 与 ws.default_tenant 为正常租户（已存在时不修改）
+func MigrateTenants(db *gorm.DB) error {
+	if err := db.AutoMigrate(&Tenant{}); err != nil {
+		return fmt.Errorf("租户表迁移失败：%w", err)
+	}
+	for _, key := range []string{"app.tenant_id", "ws.default_tenant"} {
+		tenantID := C(key)
+		if tenantID == "" {
+			continue
+		}
+		t := Tenant{TenantID: tenantID, Name: tenantID, Status: TenantActive}
+		if err := db.Unscoped().Where(Tenant{TenantID: tenantID}).FirstOrCreate(&t).Error; err != nil {
+			return fmt.Errorf("登记默认租户 %s 失败：%w", tenantID, err)
+		}
+	}
+	return nil
+}
+
+// db 租户表使用全局连接
+func (s *TenantService) db(ctx context.Context) (*gorm.DB, error) {
+	if _DB == nil {
+		return nil, errors.New("数据库未初始化")
+	}
+	return _DB.WithContext(ctx), nil
+}
+
+// Check 校验租户存在且未停用（带缓存），供 TenantVerify / WsVerify 使用；
+// 不存在返回 ErrTenantNotFound，已停用返回 ErrTenantSuspended（均已包装租户 ID）
+func (s *TenantService) Check(ctx context.Context, tenantID string) (*Tenant, error) {
+	t, err := s.lookup(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTenantNotFound, tenantID)
+	}
+	if !t.Active() {
+		return t, fmt.Errorf("%w: %s", ErrTenantSuspended, tenantID)
+	}
+	return t, nil
+}
+
+// lookup 优先读取缓存，未命中时查询数据库；不存在时返回 nil（同样缓存，避免无效租户反复查库）
+func (s *TenantService) lookup(ctx context.Context, tenantID string) (*Tenant, error) {
+	if v, ok := s.cache.Load(tenantID); ok {
+		entry := v.(tenantCacheEntry)
+		if time.Now().Before(entry.expires) {
+			return entry.tenant, nil
+		}
+		s.cache.Delete(tenantID)
+	}
+	t, err := s.Get(ctx, tenantID)
+	if errors.Is(err, ErrTenantNotFound) {
+		t, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ttl := tenantCacheTTL(); ttl > 0 {
+		s.cache.Store(tenantID, tenantCacheEntry{tenant: t, expires: time.Now().Add(ttl)})
+	}
+	return t, nil
+}
+
+// tenantCacheTTL 读取 tenant.registry_cache_ttl（秒），未配置或无效时为 60 秒
+func tenantCacheTTL() time.Duration {
+	return configSeconds("tenant.registry_cache_ttl", 60)
+}
+
+// Invalidate 清除租户缓存，不传参数时清除全部
+func (s *TenantService) Invalidate(tenantIDs ...string) {
+	if len(tenantIDs) == 0 {
+		s.cache.Range(func(key, _ interface{}) bool {
+			s.cache.Delete(key)
+			return true
+		})
+		return
+	}
+	for _, id := range tenantIDs {
+		s.cache.Delete(id)
+	}
+}
+
+// Get 查询租户（不经过缓存），不存在时返回 ErrTenantNotFound
+func (s *TenantService) Get(ctx context.Context, tenantID string) (*Tenant, error) {
+	db, err := s.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 使用 Find 而非 First：租户不存在是常见情况，避免 GORM 记录 record not found 日志
+	var t Tenant
+	result := db.Where("tenant_id = ?", tenantID).Limit(1).Find(&t)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTenantNotFound, tenantID)
+	}
+	return &t, nil
+}
+
+// List 分页查询租户，status 为空时不过滤
+func (s *TenantService) List(ctx context.Context, status string, page, size int) ([]Tenant, int64, error) {
+	db, err := s.db(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := db.Model(&Tenant{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []Tenant
+	err = query.Order("id").Offset((page - 1) * size).Limit(size).Find(&list).Error
+	return list, total, err
+}
+
+// Create 创建租户，Status 为空时为正常；tenant_id 已存在（含已删除）时返回 ErrTenantExists
+func (s *TenantService) Create(ctx context.Context, t *Tenant) (*Tenant, error) {
+	if !ValidTenantID(t.TenantID) {
+		return nil, fmt.Errorf("tenant_id %q 无效：仅允许字母、数字、_、-，不超过 32 个字符", t.TenantID)
+	}
+	if t.Status == "" {
+		t.Status = TenantActive
+	}
+	if t.Status != TenantActive && t.Status != TenantSuspended {
+		return nil, fmt.Errorf("无效的租户状态：%s", t.Status)
+	}
+	db, err := s.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var count int64
+	if err := db.Unscoped().Model(&Tenant{}).Where("tenant_id = ?", t.TenantID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTenantExists, t.TenantID)
+	}
+	if err := db.Create(t).Error; err != nil {
+		return nil, err
+	}
+	s.Invalidate(t.TenantID)
+	return t, nil
+}
+
+// Update 修改租户名称、套餐或元数据
+func (s *TenantService) Update(ctx context.Context, tenantID string, u TenantUpdate) (*Tenant, error) {
+	return s.update(ctx, tenantID, func(t *Tenant) (columns []string) {
+		if u.Name != nil {
+			t.Name, columns = *u.Name, append(columns, "name")
+		}
+		if u.Plan != nil {
+			t.Plan, columns = *u.Plan, append(columns, "plan")
+		}
+		if u.Metadata != nil {
+			t.Metadata, columns = u.Metadata, append(columns, "metadata")
+		}
+		return columns
+	})
+}
+
+// Suspend 停用租户，该租户的请求与 WS 连接握手被拒绝（已建立的连接不受影响）
+func (s *TenantService) Suspend(ctx context.Context, tenantID, reason string) (*Tenant, error) {
+	return s.update(ctx, tenantID, func(t *Tenant) []string {
+		now := time.Now()
+		t.Status, t.SuspendReason, t.SuspendedAt = TenantSuspended, reason, &now
+		return []string{"status", "suspend_reason", "suspended_at"}
+	})
+}
+
+// Reactivate 恢复已停用的租户
+func (s *TenantService) Reactivate(ctx context.Context, tenantID string) (*Tenant, error) {
+	return s.update(ctx, tenantID, func(t *Tenant) []string {
+		t.Status, t.SuspendReason, t.SuspendedAt = TenantActive, "", nil
+		return []string{"status", "suspend_reason", "suspended_at"}
+	})
+}
+
+// Delete 删除租户（软删除），不清理该租户的业务数据；
+// schema / database 模式下同时将其连接移出连接池（进行中的请求结束后关闭），后续请求返回 ErrTenantNotFound
+func (s *TenantService) Delete(ctx context.Context, tenantID string) error {
+	db, err := s.db(ctx)
+	if err != nil {
+		return err
+	}
+	result := db.Where("tenant_id = ?", tenantID).Delete(&Tenant{})
+	if result.Error != nil {
+		return result.Error
+	}
+	s.Invalidate(tenantID)
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrTenantNotFound, tenantID)
+	}
+	evictTenantStore(tenantID)
+	return nil
+}
+
+// update 按 apply 修改租户并保存其返回的列，失效缓存后返回修改后的租户
+func (s *TenantService) update(ctx context.Context, tenantID string, apply func(t *Tenant) []string) (*Tenant, error) {
+	t, err := s.Get(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	columns := apply(t)
+	if len(columns) == 0 {
+		return t, nil
+	}
+	db, err := s.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Model(t).Select(columns).Updates(t).Error; err != nil {
+		return nil, err
+	}
+	s.Invalidate(tenantID)
+	return t, nil
+}
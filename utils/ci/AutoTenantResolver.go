@@ -0,0 +1,110 @@
+package ci
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantResolverFunc 租户解析器：从请求中解析 tenant_id，未命中时返回空字符串。
+// args 为配置中 名称:参数 的参数部分，如 header:X-Tenant-ID 中的 X-Tenant-ID。
+// 解析器不得消费请求体（需读取时应读取后还原）。
+type TenantResolverFunc func(c *gin.Context, args ...string) string
+
+var (
+	tenantResolvers   = make(map[string]TenantResolverFunc)
+	tenantResolversMu sync.RWMutex
+)
+
+// defaultTenantResolvers 各分组未配置 [tenant_resolver] 时的解析顺序
+var defaultTenantResolvers = map[string]string{
+	"api":   "header,query,body,default",
+	"agent": "header,query,body,default",
+	"ws":    "header,query,default:ws.default_tenant,default",
+}
+
+// BinTenantResolver 注册具名租户解析器，可在 [tenant_resolver] 中按名称引用（同名覆盖内置解析器）：
+//
+//	ci.BinTenantResolver("shop", func(c *gin.Context, args ...string) string {
+//	    return shopTenantByDomain(c.Request.Host)
+//	})
+//
+//	[tenant_resolver]
+//	api = header,shop,default
+func BinTenantResolver(name string, fn TenantResolverFunc) {
+	if name == "" || fn == nil {
+		return
+	}
+	tenantResolversMu.Lock()
+	tenantResolvers[name] = fn
+	tenantResolversMu.Unlock()
+}
+
+// GetTenantResolver 按名称获取租户解析器
+func GetTenantResolver(name string) (TenantResolverFunc, bool) {
+	tenantResolversMu.RLock()
+	defer tenantResolversMu.RUnlock()
+	fn, ok := tenantResolvers[name]
+	return fn, ok
+}
+
+// GetTenantResolverNames 返回已注册的租户解析器名称（已排序）
+func GetTenantResolverNames() []string {
+	tenantResolversMu.RLock()
+	names := make([]string, 0, len(tenantResolvers))
+	for name := range tenantResolvers {
+		names = append(names, name)
+	}
+	tenantResolversMu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// TenantResolverSpecs 返回分组（api / agent / ws）的解析器配置 tenant_resolver.<group>，未配置时使用默认顺序
+func TenantResolverSpecs(group string) []string {
+	if specs := splitTrim(C("tenant_resolver." + group)); len(specs) > 0 {
+		return specs
+	}
+	return splitTrim(defaultTenantResolvers[group])
+}
+
+// CheckTenantResolvers 校验分组配置引用的解析器均已注册
+func CheckTenantResolvers(group string) error {
+	var unknown []string
+	for _, spec := range TenantResolverSpecs(group) {
+		name, _ := ParseMiddlewareSpec(spec)
+		if _, ok := GetTenantResolver(name); !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("tenant_resolver.%s: 解析器 %s 未注册（可用: %s）", group, strings.Join(unknown, ", "), strings.Join(GetTenantResolverNames(), ", "))
+	}
+	return nil
+}
+
+// ResolveTenant 按分组配置的顺序依次执行解析器，返回第一个非空的 tenant_id 及其来源（解析器名称），均未命中时返回空
+func ResolveTenant(c *gin.Context, group string) (tenantID, source string) {
+	for _, spec := range TenantResolverSpecs(group) {
+		name, args := ParseMiddlewareSpec(spec)
+		fn, ok := GetTenantResolver(name)
+		if !ok {
+			continue
+		}
+		if id := strings.TrimSpace(fn(c, args...)); id != "" {
+			return id, name
+		}
+	}
+	return "", ""
+}
+
+// GetTenantSource 返回当前请求 tenant_id 的来源（header / query / host / path / jwt / body / default 或自定义解析器名称）
+func GetTenantSource(c *gin.Context) string {
+	if c == nil {
+		return ""
+	}
+	return c.GetString("tenant_source")
+}
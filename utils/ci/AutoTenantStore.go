@@ -0,0 +1,349 @@
+package ci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 租户隔离模式（tenant.mode）
+const (
+	TenantModeColumn   = "column"   // 共享库表，按 tenant_id 列隔离（默认）
+	TenantModeSchema   = "schema"   // 每个租户一个 PostgreSQL schema（search_path）
+	TenantModeDatabase = "database" // 每个租户一个数据库（SQLite 为一个文件）
+)
+
+// TenantMode 返回租户隔离模式，未配置时为 column
+func TenantMode() string {
+	mode := strings.ToLower(strings.TrimSpace(C("tenant.mode")))
+	if mode == "" {
+		return TenantModeColumn
+	}
+	return mode
+}
+
+// CheckTenantMode 校验 tenant.mode 配置
+func CheckTenantMode() error {
+	switch mode := TenantMode(); mode {
+	case TenantModeColumn, TenantModeSchema, TenantModeDatabase:
+		return nil
+	default:
+		return fmt.Errorf("tenant.mode: 不支持的隔离模式 %s（可选 column / schema / database）", mode)
+	}
+}
+
+// TenantStoreOpener 打开租户的独立存储（schema / database 模式），返回该租户专用的连接池
+type TenantStoreOpener func(tenantID string) (*gorm.DB, error)
+
+// TenantStoreMigrator 在新打开的租户存储上执行迁移
+type TenantStoreMigrator func(db *gorm.DB) error
+
+// tenantStore 连接池中的租户存储，refs 为 AcquireTenantStore 持有数，大于 0 时不因空闲关闭；
+// evicted 为已移出连接池（租户被删除），最后一个持有者 release 时关闭
+type tenantStore struct {
+	once     sync.Once
+	db       *gorm.DB
+	err      error
+	lastUsed time.Time
+	refs     int
+	evicted  bool
+}
+
+var (
+	storeOpener   TenantStoreOpener
+	storeMigrator TenantStoreMigrator
+	tenantStores  = make(map[string]*tenantStore)
+	tenantStoreMu sync.Mutex
+	janitorOnce   sync.Once
+)
+
+// tenantIDPattern 合法的租户 ID：字母、数字、_、-，不超过 32 个字符（可直接用作 schema / 数据库名）
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// ValidTenantID 校验租户 ID 格式（创建租户、binding 规则 tenantid 与存储名共用）
+func ValidTenantID(tenantID string) bool {
+	return tenantIDPattern.MatchString(tenantID)
+}
+
+// SetTenantStoreOpener 设置 schema / database 模式下租户存储的打开与迁移方式（common.OpenDB 按数据库类型设置），
+// opener 为 nil 时所有租户使用全局连接（column 模式）
+func SetTenantStoreOpener(opener TenantStoreOpener, migrator TenantStoreMigrator) {
+	tenantStoreMu.Lock()
+	storeOpener, storeMigrator = opener, migrator
+	tenantStoreMu.Unlock()
+}
+
+// TenantStoreName 返回租户的 schema / 数据库名：tenant.store_prefix（默认 tenant_）+ 租户 ID（小写，- 替换为 _）；
+// 租户 ID 仅允许字母、数字、_、-，避免拼入 DDL 时注入
+func TenantStoreName(tenantID string) (string, error) {
+	if !ValidTenantID(tenantID) {
+		return "", fmt.Errorf("租户 ID %q 不能用作存储名（仅允许字母、数字、_、-，不超过 32 个字符）", tenantID)
+	}
+	prefix := C("tenant.store_prefix")
+	if prefix == "" {
+		prefix = "tenant_"
+	}
+	return strings.ToLower(prefix + strings.ReplaceAll(tenantID, "-", "_")), nil
+}
+
+// TenantStore 返回租户的 DB（不带 context）：column 模式为全局连接；
+// schema / database 模式为连接池中该租户的独立连接，首次使用时打开并迁移，空闲超过 tenant.pool_idle_timeout 后关闭。
+// 每次调用均校验租户已登记（见 tenantStoreAllowed），未登记或已删除的租户返回 ErrTenantNotFound 并关闭其已打开的连接
+func TenantStore(tenantID string) (*gorm.DB, error) {
+	tenantStoreMu.Lock()
+	opener, migrator := storeOpener, storeMigrator
+	tenantStoreMu.Unlock()
+	if opener == nil {
+		if _DB == nil {
+			return nil, errors.New("数据库未初始化")
+		}
+		return _DB, nil
+	}
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant.mode=%s 时必须指定租户", TenantMode())
+	}
+	if err := tenantStoreAllowed(tenantID); err != nil {
+		if errors.Is(err, ErrTenantNotFound) {
+			evictTenantStore(tenantID)
+		}
+		return nil, err
+	}
+
+	tenantStoreMu.Lock()
+	store, ok := tenantStores[tenantID]
+	if !ok {
+		store = &tenantStore{}
+		tenantStores[tenantID] = store
+	}
+	store.lastUsed = time.Now()
+	tenantStoreMu.Unlock()
+
+	store.once.Do(func() {
+		db, err := opener(tenantID)
+		if err == nil && migrator != nil {
+			if merr := migrator(db); merr != nil {
+				err = fmt.Errorf("租户 %s 迁移失败：%w", tenantID, merr)
+				_ = closeGormDB(db)
+				db = nil
+			}
+		}
+		tenantStoreMu.Lock()
+		// 打开期间租户被删除（见 evictTenantStore）
+		evicted := err == nil && store.evicted
+		if evicted {
+			err = fmt.Errorf("%w: %s", ErrTenantNotFound, tenantID)
+		}
+		store.err = err
+		if err == nil {
+			store.db = db
+		}
+		// 打开失败不缓存，下次请求重试
+		if err != nil && tenantStores[tenantID] == store {
+			delete(tenantStores, tenantID)
+		}
+		tenantStoreMu.Unlock()
+		if evicted {
+			_ = closeGormDB(db)
+		}
+		if err == nil {
+			janitorOnce.Do(func() { go tenantStoreJanitor() })
+		}
+	})
+	return store.db, store.err
+}
+
+// AcquireTenantStore 返回租户的 DB 并持有其连接，调用 release 前空闲回收不会关闭该连接；
+// 用于在请求结束后仍使用 DB 的场景（WebSocket 连接、异步任务），release 可重复调用，column 模式下为空操作。
+//
+//	db, release, err := ci.AcquireTenantStore(tenantID)
+//	if err != nil { return err }
+//	defer release()
+func AcquireTenantStore(tenantID string) (db *gorm.DB, release func(), err error) {
+	db, err = TenantStore(tenantID)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	tenantStoreMu.Lock()
+	store, ok := tenantStores[tenantID]
+	if !ok || store.db != db {
+		tenantStoreMu.Unlock()
+		return db, func() {}, nil
+	}
+	store.refs++
+	tenantStoreMu.Unlock()
+	var once sync.Once
+	return db, func() {
+		once.Do(func() {
+			tenantStoreMu.Lock()
+			store.refs--
+			store.lastUsed = time.Now()
+			closeNow := store.evicted && store.refs == 0
+			tenantStoreMu.Unlock()
+			if closeNow {
+				_ = closeGormDB(store.db)
+			}
+		})
+	}, nil
+}
+
+// evictTenantStore 将租户的存储移出连接池（租户被删除时），后续请求需重新校验；
+// 未被持有时立即关闭连接，否则在最后一个 release 时关闭
+func evictTenantStore(tenantID string) {
+	tenantStoreMu.Lock()
+	store, ok := tenantStores[tenantID]
+	if ok {
+		delete(tenantStores, tenantID)
+		store.evicted = true
+	}
+	closeNow := ok && store.db != nil && store.refs == 0
+	tenantStoreMu.Unlock()
+	if closeNow {
+		_ = closeGormDB(store.db)
+	}
+}
+
+// tenantStoreAllowed 校验租户已登记，避免为请求头、参数中任意的租户 ID 创建 schema / 数据库：
+// app.tenant_id、ws.default_tenant、tenant.stores 中列出的租户，以及 tenant.registry=true 时注册表中未删除的租户（含已停用）。
+// 注册表查询带缓存（tenant.registry_cache_ttl），其它实例删除的租户最多延迟该时长被拒绝
+func tenantStoreAllowed(tenantID string) error {
+	for _, id := range ConfiguredTenants() {
+		if id == tenantID {
+			return nil
+		}
+	}
+	if TenantRegistryEnabled() {
+		t, err := Tenants().lookup(context.Background(), tenantID)
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			return fmt.Errorf("%w: %s", ErrTenantNotFound, tenantID)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s（未在 tenant.stores 中登记，不创建存储）", ErrTenantNotFound, tenantID)
+}
+
+// ConfiguredTenants 返回配置中登记的租户：app.tenant_id、ws.default_tenant 与 tenant.stores（逗号分隔），已去除空值
+func ConfiguredTenants() []string {
+	var ids []string
+	for _, id := range append([]string{C("app.tenant_id"), C("ws.default_tenant")}, strings.Split(C("tenant.stores"), ",")...) {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// TenantDB 返回租户的 DB 并携带 ctx（租户、取消与超时）；打开失败时返回带错误的 DB，后续操作均返回该错误，不回退到全局连接
+func TenantDB(ctx context.Context, tenantID string) *gorm.DB {
+	db, err := TenantStore(tenantID)
+	if err != nil {
+		if _DB == nil {
+			return nil
+		}
+		db = _DB.Session(&gorm.Session{NewDB: true})
+		_ = db.AddError(err)
+		return db
+	}
+	if ctx == nil {
+		return db
+	}
+	return db.WithContext(ctx)
+}
+
+// TenantStoreIDs 返回连接池中已打开的租户（已排序）
+func TenantStoreIDs() []string {
+	tenantStoreMu.Lock()
+	ids := make([]string, 0, len(tenantStores))
+	for id, store := range tenantStores {
+		if store.db != nil {
+			ids = append(ids, id)
+		}
+	}
+	tenantStoreMu.Unlock()
+	sort.Strings(ids)
+	return ids
+}
+
+// CloseTenantStores 关闭连接池中全部租户连接（CloseDB 中调用）
+func CloseTenantStores() error {
+	tenantStoreMu.Lock()
+	stores := tenantStores
+	tenantStores = make(map[string]*tenantStore)
+	tenantStoreMu.Unlock()
+	var errs []error
+	for id, store := range stores {
+		if store.db != nil {
+			if err := closeGormDB(store.db); err != nil {
+				errs = append(errs, fmt.Errorf("关闭租户 %s 连接失败: %w", id, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// tenantStoreJanitor 每分钟关闭空闲超过 tenant.pool_idle_timeout 秒（默认 600，0 为不关闭）的租户连接
+func tenantStoreJanitor() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		if idle := configSeconds("tenant.pool_idle_timeout", 600); idle > 0 {
+			closeIdleTenantStores(idle)
+		}
+	}
+}
+
+// closeIdleTenantStores 关闭空闲超过 idle 且未被持有（见 AcquireTenantStore）的租户连接，返回关闭的租户
+func closeIdleTenantStores(idle time.Duration) []string {
+	var (
+		ids     []string
+		expired []*tenantStore
+	)
+	tenantStoreMu.Lock()
+	for id, store := range tenantStores {
+		if store.db != nil && store.refs == 0 && time.Since(store.lastUsed) > idle {
+			delete(tenantStores, id)
+			ids = append(ids, id)
+			expired = append(expired, store)
+		}
+	}
+	tenantStoreMu.Unlock()
+	for _, store := range expired {
+		_ = closeGormDB(store.db)
+	}
+	return ids
+}
+
+// TenantPoolLimits 返回每个租户连接的最大打开数与最大空闲数（tenant.pool_max_open 默认 10，tenant.pool_max_idle 默认 2）
+func TenantPoolLimits() (maxOpen, maxIdle int) {
+	return configInt("tenant.pool_max_open", 10), configInt("tenant.pool_max_idle", 2)
+}
+
+func configInt(key string, def int) int {
+	n, err := strconv.Atoi(C(key))
+	if err != nil || n < 0 {
+		return def
+	}
+	return n
+}
+
+func configSeconds(key string, def int) time.Duration {
+	return time.Duration(configInt(key, def)) * time.Second
+}
+
+func closeGormDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
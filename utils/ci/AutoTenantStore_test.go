@@ -0,0 +1,140 @@
+package ci
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// setTestConfig 以 values（section.key → 值）作为配置，测试不读取 config.ini
+func setTestConfig(values map[string]string) {
+	once.Do(func() { instance = &Config{data: make(map[string]map[string]interface{})} })
+	for key, v := range values {
+		section, name, _ := strings.Cut(key, ".")
+		if instance.data[section] == nil {
+			instance.data[section] = make(map[string]interface{})
+		}
+		instance.data[section][name] = v
+	}
+}
+
+// openedStore 将 db 作为已打开的租户存储放入连接池，lastUsed 设为 idle 之前
+func openedStore(t *testing.T, tenantID string, db *gorm.DB, idle time.Duration) {
+	t.Helper()
+	store := &tenantStore{db: db, lastUsed: time.Now().Add(-2 * idle)}
+	store.once.Do(func() {})
+	tenantStoreMu.Lock()
+	tenantStores[tenantID] = store
+	tenantStoreMu.Unlock()
+}
+
+func TestCloseIdleTenantStoresSkipsHeld(t *testing.T) {
+	setTestConfig(map[string]string{"tenant.stores": "t1,t2", "tenant.registry": "false"})
+	SetTenantStoreOpener(func(string) (*gorm.DB, error) { return nil, errors.New("不应重新打开") }, nil)
+	defer SetTenantStoreOpener(nil, nil)
+	defer CloseTenantStores()
+
+	const idle = time.Minute
+	openedStore(t, "t1", newPluginDB(t), idle)
+	openedStore(t, "t2", newPluginDB(t), idle)
+
+	db, release, err := AcquireTenantStore("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// TenantStore 会刷新 lastUsed，重新设为空闲以验证持有计数
+	tenantStoreMu.Lock()
+	tenantStores["t1"].lastUsed = time.Now().Add(-2 * idle)
+	tenantStoreMu.Unlock()
+
+	if closed := closeIdleTenantStores(idle); len(closed) != 1 || closed[0] != "t2" {
+		t.Fatalf("closed = %v, want [t2]", closed)
+	}
+	if err := db.Exec("SELECT 1").Error; err != nil {
+		t.Fatalf("持有中的连接被关闭: %v", err)
+	}
+
+	release()
+	release() // 重复调用无副作用
+	tenantStoreMu.Lock()
+	refs := tenantStores["t1"].refs
+	tenantStores["t1"].lastUsed = time.Now().Add(-2 * idle)
+	tenantStoreMu.Unlock()
+	if refs != 0 {
+		t.Fatalf("release 后 refs = %d, want 0", refs)
+	}
+	if closed := closeIdleTenantStores(idle); len(closed) != 1 || closed[0] != "t1" {
+		t.Fatalf("释放后 closed = %v, want [t1]", closed)
+	}
+}
+
+func TestDeletedTenantStoreEvicted(t *testing.T) {
+	setTestConfig(map[string]string{"tenant.stores": "", "tenant.registry": "true", "tenant.registry_cache_ttl": "60"})
+	defer setTestConfig(map[string]string{"tenant.registry": "false"})
+	registry := newPluginDB(t)
+	if err := MigrateTenants(registry); err != nil {
+		t.Fatal(err)
+	}
+	SetDB(registry)
+	defer SetDB(nil)
+	var opened atomic.Int32
+	SetTenantStoreOpener(func(string) (*gorm.DB, error) {
+		opened.Add(1)
+		return newPluginDB(t), nil
+	}, nil)
+	defer SetTenantStoreOpener(nil, nil)
+	defer CloseTenantStores()
+
+	ctx := context.Background()
+	for _, id := range []string{"t3", "t4"} {
+		if _, err := Tenants().Create(ctx, &Tenant{TenantID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 删除时进行中的请求继续使用连接，release 后关闭；之后的请求返回 ErrTenantNotFound
+	db, release, err := AcquireTenantStore("t3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Tenants().Delete(ctx, "t3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("SELECT 1").Error; err != nil {
+		t.Fatalf("持有中的连接被关闭: %v", err)
+	}
+	if _, err := TenantStore("t3"); !errors.Is(err, ErrTenantNotFound) {
+		t.Fatalf("删除后 TenantStore err = %v, want ErrTenantNotFound", err)
+	}
+	release()
+	if err := db.Exec("SELECT 1").Error; err == nil {
+		t.Fatal("release 后已删除租户的连接应关闭")
+	}
+
+	// 其它实例删除（连接仍在本实例的连接池中），缓存失效后同样拒绝
+	db, err = TenantStore("t4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Where("tenant_id = ?", "t4").Delete(&Tenant{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	Tenants().Invalidate("t4")
+	if _, err := TenantStore("t4"); !errors.Is(err, ErrTenantNotFound) {
+		t.Fatalf("已删除租户的缓存连接 err = %v, want ErrTenantNotFound", err)
+	}
+	if ids := TenantStoreIDs(); len(ids) != 0 {
+		t.Fatalf("TenantStoreIDs = %v, want empty", ids)
+	}
+	if err := db.Exec("SELECT 1").Error; err == nil {
+		t.Fatal("已删除租户的连接应关闭")
+	}
+	if n := opened.Load(); n != 2 {
+		t.Fatalf("opened = %d, want 2", n)
+	}
+}
@@ -0,0 +1,404 @@
+package ci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`           // 字段路径（json 名称），嵌套字段以 . 分隔，如 items[0].name
+	Rule    string `json:"rule"`            // 未通过的规则，如 required、phone
+	Param   string `json:"param,omitempty"` // 规则参数，如 min=6 中的 6
+	Message string `json:"message"`         // 已按语言翻译的错误消息
+}
+
+// ValidationError 参数校验错误，自动路由以 CodeInvalidParams 响应，data 为 {"errors": [...]}
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// validatorLocales 支持的错误消息语言
+var validatorLocales = []string{"zh", "en"}
+
+// validatorRule 已注册的校验规则
+type validatorRule struct {
+	tag      string
+	fn       validator.Func
+	fnCtx    validator.FuncCtx
+	messages map[string]string
+}
+
+var (
+	validatorOnce   sync.Once
+	validatorEngine *validator.Validate
+	translators     = make(map[string]ut.Translator)
+	validatorRules  = make(map[string]*validatorRule)
+	validatorMu     sync.Mutex
+)
+
+func init() {
+	RegisterValidator("phone", ValidatePhone, map[string]string{
+		"zh": "{0}必须是有效的手机号",
+		"en": "{0} must be a valid mobile phone number",
+	})
+	RegisterValidator("idcard", ValidateIDCard, map[string]string{
+		"zh": "{0}必须是有效的身份证号",
+		"en": "{0} must be a valid ID card number",
+	})
+	RegisterValidator("tenantid", func(fl validator.FieldLevel) bool {
+		return ValidTenantID(fl.Field().String())
+	}, map[string]string{
+		"zh": "{0}仅允许字母、数字、_、-，不超过 32 个字符",
+		"en": "{0} may only contain letters, digits, _ and -, up to 32 characters",
+	})
+	RegisterValidatorCtx("tenant_unique", validateTenantUnique, map[string]string{
+		"zh": "{0}已存在",
+		"en": "{0} already exists",
+	})
+}
+
+// RegisterValidator 注册具名校验规则，在 binding 标签中使用；messages 为各语言（zh、en）的错误消息，{0} 为字段名，{1} 为规则参数：
+//
+//	ci.RegisterValidator("sku", func(fl validator.FieldLevel) bool {
+//	    return skuPattern.MatchString(fl.Field().String())
+//	}, map[string]string{"zh": "{0}不是有效的 SKU", "en": "{0} is not a valid SKU"})
+//
+//	type CreateReq struct {
+//	    Sku string `json:"sku" binding:"required,sku"`
+//	}
+func RegisterValidator(tag string, fn validator.Func, messages map[string]string) {
+	if tag == "" || fn == nil {
+		return
+	}
+	addValidatorRule(&validatorRule{tag: tag, fn: fn, messages: messages})
+}
+
+// RegisterValidatorCtx 注册需要请求上下文的校验规则（如查询数据库），可通过 TenantIDFrom(ctx) 获取当前租户、DBFrom(ctx) 获取租户 DB
+func RegisterValidatorCtx(tag string, fn validator.FuncCtx, messages map[string]string) {
+	if tag == "" || fn == nil {
+		return
+	}
+	addValidatorRule(&validatorRule{tag: tag, fnCtx: fn, messages: messages})
+}
+
+func addValidatorRule(rule *validatorRule) {
+	validatorMu.Lock()
+	validatorRules[rule.tag] = rule
+	validatorMu.Unlock()
+	if v := Validator(); v != nil {
+		applyValidatorRule(v, rule)
+	}
+}
+
+// Validator 返回 gin 使用的校验器实例（首次调用时设置字段名与中英文翻译），binding.Validator 被替换为其它实现时返回 nil
+func Validator() *validator.Validate {
+	validatorOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			fmt.Printf("[validator] binding.Validator 不是 go-playground/validator，跳过自定义规则与翻译\n")
+			return
+		}
+		// 错误中的字段名使用 json 名称（其次为 form 名称）
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			for _, tag := range []string{"json", "form"} {
+				name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return f.Name
+		})
+		uni := ut.New(zh.New(), zh.New(), en.New())
+		for _, locale := range validatorLocales {
+			trans, _ := uni.GetTranslator(locale)
+			var err error
+			if locale == "zh" {
+				err = zhTranslations.RegisterDefaultTranslations(v, trans)
+			} else {
+				err = enTranslations.RegisterDefaultTranslations(v, trans)
+			}
+			if err != nil {
+				fmt.Printf("[validator] 注册 %s 翻译失败: %v\n", locale, err)
+				continue
+			}
+			translators[locale] = trans
+		}
+		validatorEngine = v
+		validatorMu.Lock()
+		defer validatorMu.Unlock()
+		for _, rule := range validatorRules {
+			applyValidatorRule(v, rule)
+		}
+	})
+	return validatorEngine
+}
+
+// applyValidatorRule 将规则及其翻译注册到校验器
+func applyValidatorRule(v *validator.Validate, rule *validatorRule) {
+	var err error
+	if rule.fnCtx != nil {
+		err = v.RegisterValidationCtx(rule.tag, rule.fnCtx)
+	} else {
+		err = v.RegisterValidation(rule.tag, rule.fn)
+	}
+	if err != nil {
+		fmt.Printf("[validator] 注册规则 %s 失败: %v\n", rule.tag, err)
+		return
+	}
+	for locale, msg := range rule.messages {
+		trans, ok := translators[locale]
+		if !ok || msg == "" {
+			continue
+		}
+		_ = v.RegisterTranslation(rule.tag, trans, func(ut ut.Translator) error {
+			return ut.Add(rule.tag, msg, true)
+		}, func(ut ut.Translator, fe validator.FieldError) string {
+			s, _ := ut.T(fe.Tag(), fe.Field(), fe.Param())
+			return s
+		})
+	}
+}
+
+// ValidatorLocale 返回当前请求的错误消息语言：Accept-Language 命中 zh/en 时使用之，否则为 validator.locale（默认 zh）
+func ValidatorLocale(c *gin.Context) string {
+	if c != nil && c.Request != nil {
+		for _, part := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+			lang, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+			lang = strings.ToLower(lang)
+			for _, locale := range validatorLocales {
+				if lang == locale || strings.HasPrefix(lang, locale+"-") {
+					return locale
+				}
+			}
+		}
+	}
+	if locale := strings.ToLower(C("validator.locale")); locale != "" {
+		for _, l := range validatorLocales {
+			if l == locale {
+				return l
+			}
+		}
+	}
+	return "zh"
+}
+
+// Validate 按 binding 标签校验结构体，失败时返回 *ValidationError（消息按请求语言翻译）
+//
+//	if err := ci.Validate(c, &req); err != nil {
+//	    ci.ValidationFailed(c, err)
+//	    return
+//	}
+func Validate(c *gin.Context, obj interface{}) error {
+	v := Validator()
+	if v == nil {
+		if binding.Validator == nil {
+			return nil
+		}
+		return binding.Validator.ValidateStruct(obj)
+	}
+	return TranslateValidation(c, v.StructCtx(validationContext(c), obj))
+}
+
+// validationContext 构造校验上下文：请求的 context（含请求作用域），尚未绑定租户作用域时以 c 中的 tenant_id 补充，供 RegisterValidatorCtx 规则使用
+func validationContext(c *gin.Context) context.Context {
+	if c == nil || c.Request == nil {
+		return context.Background()
+	}
+	ctx := c.Request.Context()
+	if tenantID := GetTenantID(c); tenantID != "" && TenantIDFrom(ctx) == "" {
+		ctx = NewContext(ctx, &Scope{TenantID: tenantID})
+	}
+	return ctx
+}
+
+// TranslateValidation 将 validator 校验错误与 JSON 类型错误转换为 *ValidationError，其它错误原样返回
+func TranslateValidation(c *gin.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	locale := ValidatorLocale(c)
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		out := &ValidationError{Errors: make([]FieldError, 0, len(verrs))}
+		for _, fe := range verrs {
+			out.Errors = append(out.Errors, FieldError{
+				Field:   fieldPath(fe.Namespace()),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: translateFieldError(fe, locale),
+			})
+		}
+		return out
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		msg := fmt.Sprintf("%s类型错误，应为%s", typeErr.Field, typeErr.Type)
+		if locale == "en" {
+			msg = fmt.Sprintf("%s must be of type %s", typeErr.Field, typeErr.Type)
+		}
+		return &ValidationError{Errors: []FieldError{{Field: typeErr.Field, Rule: "type", Param: typeErr.Type.String(), Message: msg}}}
+	}
+	return err
+}
+
+// translateFieldError 翻译单个字段错误，未注册消息的规则使用通用消息
+func translateFieldError(fe validator.FieldError, locale string) string {
+	if trans, ok := translators[locale]; ok {
+		if msg := fe.Translate(trans); msg != fe.Error() {
+			return msg
+		}
+	}
+	if locale == "en" {
+		return fmt.Sprintf("%s failed on the '%s' rule", fe.Field(), fe.Tag())
+	}
+	return fmt.Sprintf("%s格式不正确", fe.Field())
+}
+
+// fieldPath 去掉命名空间中的结构体名：CreateReq.items[0].name → items[0].name
+func fieldPath(namespace string) string {
+	if _, rest, ok := strings.Cut(namespace, "."); ok {
+		return rest
+	}
+	return namespace
+}
+
+// ValidationFailed 以 CodeInvalidParams 响应参数错误：*ValidationError 在 data.errors 中返回字段错误列表
+func ValidationFailed(c *gin.Context, err error) {
+	var verr *ValidationError
+	if errors.As(err, &verr) && len(verr.Errors) > 0 {
+		Custom(c, CodeInvalidParams, verr.Errors[0].Message, gin.H{"errors": verr.Errors})
+		return
+	}
+	Error(c, CodeInvalidParams, err.Error())
+}
+
+// tenantUniqueTable tenant_unique 表名解析结果
+type tenantUniqueTable struct {
+	name string // 实际表名
+	soft bool   // 含 deleted_at 列（软删除记录不参与唯一性判断）
+}
+
+// tenantUniqueTables 缓存规则中的表名到 tenantUniqueTable 的解析结果，仅在查询成功后写入
+var tenantUniqueTables sync.Map
+
+// resolveTenantUniqueTable 解析实际表名：表存在时原样使用（已写全表名），否则按 NamingStrategy 补全前缀（如 members → pre_members）
+func resolveTenantUniqueTable(db *gorm.DB, table string) tenantUniqueTable {
+	if v, ok := tenantUniqueTables.Load(table); ok {
+		return v.(tenantUniqueTable)
+	}
+	m := db.Migrator()
+	name := table
+	if !m.HasTable(name) {
+		name = db.NamingStrategy.TableName(table)
+	}
+	return tenantUniqueTable{name: name, soft: m.HasColumn(name, "deleted_at")}
+}
+
+// validateTenantUnique 租户内唯一：tenant_unique=表名.列名，省略列名时使用字段名；表名可省略前缀（按 NamingStrategy 补全）。
+// 字段为空时不校验（配合 required 使用），存在 tenant_id 时仅在当前租户内判断；
+// 规则配置有误或数据库不可用时校验失败并记录日志。
+// 修改场景可追加主键字段（空格分隔，结构体字段名或 json 名），该字段非零时排除主键 id 等于其值的记录（即自身）。
+//
+//	Phone string `json:"phone" binding:"required,phone,tenant_unique=members.phone"`
+//	Phone string `json:"phone" binding:"required,phone,tenant_unique=members.phone id"`
+func validateTenantUnique(ctx context.Context, fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.IsZero() {
+		return true
+	}
+	params := strings.Fields(fl.Param())
+	if len(params) == 0 || len(params) > 2 {
+		fmt.Printf("[validator] tenant_unique 规则 %q 格式错误，应为 表名.列名 [主键字段]\n", fl.Param())
+		return false
+	}
+	table, column, _ := strings.Cut(params[0], ".")
+	if column == "" {
+		column = fl.FieldName()
+	}
+	if table == "" {
+		fmt.Printf("[validator] tenant_unique 规则 %q 缺少表名\n", fl.Param())
+		return false
+	}
+	db := DBFrom(ctx)
+	if db == nil {
+		fmt.Printf("[validator] tenant_unique 校验 %s.%s 失败: 数据库未初始化\n", table, column)
+		return false
+	}
+	resolved := resolveTenantUniqueTable(db.WithContext(ctx), table)
+	query := db.WithContext(ctx).Table(resolved.name).Where(clause.Eq{Column: clause.Column{Name: column}, Value: field.Interface()})
+	tenantID := TenantIDFrom(ctx)
+	if tenantID != "" {
+		query = query.Where(clause.Eq{Column: clause.Column{Name: "tenant_id"}, Value: tenantID})
+	}
+	if len(params) == 2 {
+		self, ok := structField(fl.Parent(), params[1])
+		if !ok {
+			fmt.Printf("[validator] tenant_unique 排除字段 %s 不存在\n", params[1])
+			return false
+		}
+		if !self.IsZero() {
+			query = query.Where(clause.Neq{Column: clause.Column{Name: "id"}, Value: self.Interface()})
+		}
+	}
+	if resolved.soft {
+		query = query.Where(clause.Eq{Column: clause.Column{Name: "deleted_at"}, Value: nil})
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		fmt.Printf("[validator] tenant_unique 查询 %s.%s（租户 %q）失败，按校验不通过处理: %v\n", resolved.name, column, tenantID, err)
+		return false
+	}
+	tenantUniqueTables.Store(table, resolved)
+	return count == 0
+}
+
+// structField 按结构体字段名或 json 名查找字段（含嵌入字段提升的字段）
+func structField(parent reflect.Value, name string) (reflect.Value, bool) {
+	for parent.Kind() == reflect.Ptr {
+		if parent.IsNil() {
+			return reflect.Value{}, false
+		}
+		parent = parent.Elem()
+	}
+	if parent.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	if f := parent.FieldByName(name); f.IsValid() {
+		return f, true
+	}
+	t := parent.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); jsonName == name {
+			return parent.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,67 @@
+package ci
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+type uniqueMember struct {
+	Model
+	Phone string
+}
+
+type uniqueMemberReq struct {
+	Phone string `json:"phone" binding:"tenant_unique=unique_member.phone"`
+}
+
+type uniqueMemberFullReq struct {
+	Phone string `json:"phone" binding:"tenant_unique=pre_unique_member.phone"`
+}
+
+func TestTenantUniquePrefixedTable(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "unique.db")), &gorm.Config{
+		Logger:         logger.Discard,
+		NamingStrategy: schema.NamingStrategy{TablePrefix: "pre_", SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(TenantPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&uniqueMember{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tenantDB(db, "t1").Create(&uniqueMember{Phone: "13800000000"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	v := Validator()
+	validate := func(tenantID string, req interface{}) error {
+		return v.StructCtx(NewContext(context.Background(), &Scope{TenantID: tenantID, DB: db}), req)
+	}
+
+	if err := validate("t1", &uniqueMemberReq{Phone: "13800000000"}); err == nil {
+		t.Fatal("省略前缀的表名应按 NamingStrategy 补全并发现重复")
+	}
+	if err := validate("t1", &uniqueMemberFullReq{Phone: "13800000000"}); err == nil {
+		t.Fatal("完整表名应发现重复")
+	}
+	if err := validate("t2", &uniqueMemberReq{Phone: "13800000000"}); err != nil {
+		t.Fatalf("其它租户不应冲突: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Close()
+	if err := validate("t2", &uniqueMemberReq{Phone: "13900000000"}); err == nil {
+		t.Fatal("数据库不可用时校验应失败")
+	}
+}
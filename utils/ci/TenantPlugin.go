@@ -0,0 +1,203 @@
+package ci
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// TenantPluginName 租户隔离插件名称
+const TenantPluginName = "caleyi:tenant"
+
+const (
+	withoutTenantKey = "caleyi:without_tenant" // WithoutTenant 设置的跳过标记
+	tenantScopedKey  = "caleyi:tenant_scoped"  // 已追加租户条件的 Statement（Settings 会复制到 Preload 等派生语句，需比较指针）
+)
+
+// TenantPlugin GORM 租户隔离插件：对嵌入 ci.Model 的模型，
+//   - 查询（query / row）、更新、删除自动追加 tenant_id = 当前租户 条件
+//   - 创建时填充 tenant_id，拒绝写入其它租户的数据
+//   - 通过 Model 指定租户模型的原生 SQL（db.Model(&X{}).Exec / Raw）无法改写，一律拒绝执行；
+//     未指定 Model 的原生 SQL（db.Raw(...).Scan(&list)）插件无法识别所属模型，不在隔离范围内，须自行添加 tenant_id 条件
+//
+// 当前租户取自 Statement.Context（见 TenantIDFrom；TenantVerify / ci.MC / ci.Go / ci.MT 已自动设置），
+// 缺失时返回错误；需跨租户操作（后台统计、迁移脚本等）时使用 WithoutTenant 显式跳过。
+type TenantPlugin struct{}
+
+// Name 插件名称
+func (TenantPlugin) Name() string {
+	return TenantPluginName
+}
+
+// Initialize 注册回调
+func (p TenantPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("caleyi:tenant_create", p.create),
+		cb.Query().Before("gorm:query").Register("caleyi:tenant_query", p.scope),
+		cb.Row().Before("gorm:row").Register("caleyi:tenant_row", p.scope),
+		cb.Update().Before("gorm:update").Register("caleyi:tenant_update", p.scope),
+		cb.Delete().Before("gorm:delete").Register("caleyi:tenant_delete", p.scope),
+		cb.Raw().Before("gorm:raw").Register("caleyi:tenant_raw", p.scope),
+	)
+}
+
+// UseTenantPlugin 为数据库连接注册租户隔离插件（重复调用无副作用）；tenant.auth=false 时不注册
+func UseTenantPlugin(db *gorm.DB) error {
+	if db == nil || C("tenant.auth") == "false" {
+		return nil
+	}
+	if _, ok := db.Config.Plugins[TenantPluginName]; ok {
+		return nil
+	}
+	if err := db.Use(TenantPlugin{}); err != nil {
+		return fmt.Errorf("注册租户隔离插件失败: %w", err)
+	}
+	return nil
+}
+
+// WithoutTenant 跳过租户隔离的 GORM scope，仅用于确需跨租户的操作：
+//
+//	ci.D().Scopes(ci.WithoutTenant).Model(&models.Order{}).Count(&total)
+//	ci.WithoutTenant(ci.D()).Find(&all)
+func WithoutTenant(db *gorm.DB) *gorm.DB {
+	return db.Set(withoutTenantKey, true)
+}
+
+// tenantModelType ci.Model 提供的方法，嵌入 ci.Model 的模型（指针）均实现该接口
+var tenantModelType = reflect.TypeOf((*interface{ GetTenant() string })(nil)).Elem()
+
+// tenantModels 缓存模型类型是否为租户模型
+var tenantModels sync.Map
+
+// tenantField 返回租户模型的 tenant_id 字段，非租户模型返回 nil
+func tenantField(s *schema.Schema) *schema.Field {
+	if s == nil || s.ModelType == nil {
+		return nil
+	}
+	scoped, ok := tenantModels.Load(s.ModelType)
+	if !ok {
+		scoped = reflect.PointerTo(s.ModelType).Implements(tenantModelType) && s.LookUpField("tenant_id") != nil
+		tenantModels.Store(s.ModelType, scoped)
+	}
+	if !scoped.(bool) {
+		return nil
+	}
+	return s.LookUpField("tenant_id")
+}
+
+// currentTenant 返回语句所属租户；跳过隔离或非租户模型时 skip 为 true
+func currentTenant(db *gorm.DB) (tenantID string, field *schema.Field, skip bool) {
+	if db.Error != nil {
+		return "", nil, true
+	}
+	if v, ok := db.Get(withoutTenantKey); ok && v == true {
+		return "", nil, true
+	}
+	field = tenantField(db.Statement.Schema)
+	if field == nil {
+		return "", nil, true
+	}
+	if db.Statement.Context != nil {
+		tenantID = TenantIDFrom(db.Statement.Context)
+	}
+	return tenantID, field, false
+}
+
+// scope 为查询、更新、删除追加租户条件；已有 SQL 的原生语句无法改写，拒绝执行（需自行添加 tenant_id 条件并使用 WithoutTenant）
+func (TenantPlugin) scope(db *gorm.DB) {
+	tenantID, field, skip := currentTenant(db)
+	if skip {
+		return
+	}
+	stmt := db.Statement
+	if stmt.SQL.Len() > 0 {
+		_ = db.AddError(fmt.Errorf("【TenantPlugin】%s 的原生 SQL 无法追加 %s 条件，请改用查询构造器，或自行添加条件后使用 ci.WithoutTenant", stmt.Schema.Name, field.DBName))
+		return
+	}
+	if tenantID == "" {
+		_ = db.AddError(fmt.Errorf("【TenantPlugin】tenant ID not found in context（%s），跨租户操作请使用 ci.WithoutTenant", stmt.Schema.Name))
+		return
+	}
+	if v, ok := stmt.Settings.Load(tenantScopedKey); ok && v == stmt {
+		return
+	}
+	stmt.Settings.Store(tenantScopedKey, stmt)
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantID},
+	}})
+}
+
+// create 填充 tenant_id：未设置时取当前租户；已设置且与当前租户不同时拒绝写入。
+// 无租户上下文时保留手动设置的值（record.SetTenant(id).Create()），未设置则返回错误。
+func (TenantPlugin) create(db *gorm.DB) {
+	tenantID, field, skip := currentTenant(db)
+	if skip {
+		return
+	}
+	stmt := db.Statement
+	check := func(current string) (string, error) {
+		switch {
+		case current == "" && tenantID == "":
+			return "", fmt.Errorf("【TenantPlugin】tenant ID not found in context（%s）", stmt.Schema.Name)
+		case current == "":
+			return tenantID, nil
+		case tenantID != "" && current != tenantID:
+			return "", fmt.Errorf("【TenantPlugin】禁止写入其它租户的数据（%s: %s ≠ %s）", stmt.Schema.Name, current, tenantID)
+		}
+		return current, nil
+	}
+	setStruct := func(rv reflect.Value) {
+		current, _ := field.ValueOf(stmt.Context, rv)
+		s, _ := current.(string)
+		id, err := check(s)
+		if err != nil {
+			_ = db.AddError(err)
+			return
+		}
+		if id != s {
+			_ = db.AddError(field.Set(stmt.Context, rv, id))
+		}
+	}
+	setMap := func(m map[string]interface{}) {
+		key := field.DBName
+		if _, ok := m[key]; !ok {
+			key = field.Name
+		}
+		s, _ := m[key].(string)
+		id, err := check(s)
+		if err != nil {
+			_ = db.AddError(err)
+			return
+		}
+		m[key] = id
+	}
+
+	switch dest := stmt.Dest.(type) {
+	case map[string]interface{}:
+		setMap(dest)
+		return
+	case []map[string]interface{}:
+		for _, m := range dest {
+			setMap(m)
+		}
+		return
+	}
+	rv := stmt.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			elem := reflect.Indirect(rv.Index(i))
+			if elem.Kind() == reflect.Struct {
+				setStruct(elem)
+			}
+		}
+	case reflect.Struct:
+		setStruct(rv)
+	}
+}
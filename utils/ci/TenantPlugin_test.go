@@ -0,0 +1,215 @@
+package ci
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type pluginOrder struct {
+	Model
+	No    string
+	Items []pluginItem `gorm:"foreignKey:OrderID"`
+}
+
+type pluginItem struct {
+	Model
+	OrderID uint
+	Name    string
+}
+
+// newPluginDB 创建注册了 TenantPlugin 的 SQLite 库，租户 t1、t2 各有一个订单及明细
+func newPluginDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "tenant.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(TenantPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&pluginOrder{}, &pluginItem{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tenantID := range []string{"t1", "t2"} {
+		order := pluginOrder{No: tenantID + "-order", Items: []pluginItem{{Name: tenantID + "-item"}}}
+		if err := db.WithContext(TenantContext(tenantID)).Create(&order).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func tenantDB(db *gorm.DB, tenantID string) *gorm.DB {
+	return db.WithContext(TenantContext(tenantID))
+}
+
+func TestTenantPluginCreate(t *testing.T) {
+	db := newPluginDB(t)
+	var order pluginOrder
+	if err := WithoutTenant(db).Preload("Items").Where("no = ?", "t1-order").First(&order).Error; err != nil {
+		t.Fatal(err)
+	}
+	if order.TenantID != "t1" {
+		t.Fatalf("tenant_id = %q, want t1", order.TenantID)
+	}
+	for _, item := range order.Items {
+		if item.TenantID != "t1" {
+			t.Fatalf("item tenant_id = %q, want t1", item.TenantID)
+		}
+	}
+
+	other := pluginOrder{No: "x", Model: Model{TenantID: "t2"}}
+	if err := tenantDB(db, "t1").Create(&other).Error; err == nil {
+		t.Fatal("写入其它租户的数据应被拒绝")
+	}
+	if err := db.Create(&pluginOrder{No: "y"}).Error; err == nil {
+		t.Fatal("无租户上下文且未设置 tenant_id 时应拒绝写入")
+	}
+	manual := pluginOrder{No: "z"}
+	manual.SetTenant("t2")
+	if err := db.Create(&manual).Error; err != nil {
+		t.Fatalf("手动设置 tenant_id 应允许写入: %v", err)
+	}
+}
+
+func TestTenantPluginQuery(t *testing.T) {
+	db := newPluginDB(t)
+
+	var list []pluginOrder
+	if err := tenantDB(db, "t1").Find(&list).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].No != "t1-order" {
+		t.Fatalf("Find = %+v, want only t1-order", list)
+	}
+
+	var order pluginOrder
+	err := tenantDB(db, "t1").Where("no = ?", "t2-order").First(&order).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("First 其它租户的数据 err = %v, want ErrRecordNotFound", err)
+	}
+
+	var count int64
+	if err := tenantDB(db, "t1").Model(&pluginOrder{}).Count(&count).Error; err != nil || count != 1 {
+		t.Fatalf("Count = %d, %v, want 1", count, err)
+	}
+
+	var n int64
+	if err := tenantDB(db, "t2").Model(&pluginOrder{}).Select("COUNT(*)").Row().Scan(&n); err != nil || n != 1 {
+		t.Fatalf("Row().Scan = %d, %v, want 1", n, err)
+	}
+
+	var nos []string
+	if err := tenantDB(db, "t2").Model(&pluginOrder{}).Pluck("no", &nos).Error; err != nil || len(nos) != 1 || nos[0] != "t2-order" {
+		t.Fatalf("Pluck = %v, %v, want [t2-order]", nos, err)
+	}
+
+	if err := db.Find(&list).Error; err == nil {
+		t.Fatal("无租户上下文时查询应返回错误")
+	}
+}
+
+func TestTenantPluginUpdateDelete(t *testing.T) {
+	db := newPluginDB(t)
+
+	result := tenantDB(db, "t1").Model(&pluginOrder{}).Where("1 = 1").Updates(map[string]interface{}{"no": "changed"})
+	if result.Error != nil || result.RowsAffected != 1 {
+		t.Fatalf("Updates = %d, %v, want 1 row", result.RowsAffected, result.Error)
+	}
+	var order pluginOrder
+	if err := tenantDB(db, "t2").First(&order).Error; err != nil || order.No != "t2-order" {
+		t.Fatalf("t2 的订单被修改: %+v, %v", order, err)
+	}
+
+	result = tenantDB(db, "t1").Where("no = ?", "t2-order").Delete(&pluginOrder{})
+	if result.Error != nil || result.RowsAffected != 0 {
+		t.Fatalf("Delete 其它租户的数据 = %d, %v, want 0 row", result.RowsAffected, result.Error)
+	}
+	result = tenantDB(db, "t2").Where("no = ?", "t2-order").Delete(&pluginOrder{})
+	if result.Error != nil || result.RowsAffected != 1 {
+		t.Fatalf("Delete = %d, %v, want 1 row", result.RowsAffected, result.Error)
+	}
+}
+
+func TestTenantPluginRaw(t *testing.T) {
+	db := newPluginDB(t)
+
+	var list []pluginOrder
+	if err := tenantDB(db, "t1").Model(&pluginOrder{}).Raw("SELECT * FROM plugin_orders WHERE tenant_id = ?", "t1").Scan(&list).Error; err == nil {
+		t.Fatal("含 tenant_id 的原生 SQL 同样应被拒绝（无法校验条件）")
+	}
+	if err := tenantDB(db, "t1").Model(&pluginOrder{}).Exec("UPDATE plugin_orders SET no = ?", "x").Error; err == nil {
+		t.Fatal("租户模型的 Exec 应被拒绝")
+	}
+
+	// 未指定 Model 的原生 SQL 无法识别所属模型，不受隔离保护
+	var n int64
+	if err := tenantDB(db, "t1").Raw("SELECT COUNT(*) FROM plugin_orders").Scan(&n).Error; err != nil || n != 2 {
+		t.Fatalf("未指定 Model 的原生 SQL = %d, %v, want 2", n, err)
+	}
+	err := tenantDB(db, "t1").Scopes(WithoutTenant).Raw("SELECT * FROM plugin_orders WHERE tenant_id = ?", "t1").Scan(&list).Error
+	if err != nil || len(list) != 1 {
+		t.Fatalf("WithoutTenant 的原生 SQL = %d, %v, want 1", len(list), err)
+	}
+}
+
+func TestTenantPluginPreload(t *testing.T) {
+	db := newPluginDB(t)
+	// 脏数据：t2 的明细挂在 t1 的订单下，Preload 不应加载
+	var t1Order pluginOrder
+	if err := tenantDB(db, "t1").First(&t1Order).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := tenantDB(db, "t2").Create(&pluginItem{OrderID: t1Order.ID, Name: "leak"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var list []pluginOrder
+	if err := tenantDB(db, "t1").Preload("Items").Find(&list).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || len(list[0].Items) != 1 || list[0].Items[0].Name != "t1-item" {
+		t.Fatalf("Preload = %+v, want only t1-item", list)
+	}
+}
+
+func TestTenantPluginWithoutTenant(t *testing.T) {
+	db := newPluginDB(t)
+
+	var count int64
+	if err := db.Scopes(WithoutTenant).Model(&pluginOrder{}).Count(&count).Error; err != nil || count != 2 {
+		t.Fatalf("WithoutTenant Count = %d, %v, want 2", count, err)
+	}
+	var list []pluginOrder
+	if err := WithoutTenant(tenantDB(db, "t1")).Find(&list).Error; err != nil || len(list) != 2 {
+		t.Fatalf("WithoutTenant Find = %d, %v, want 2", len(list), err)
+	}
+	result := WithoutTenant(db).Model(&pluginOrder{}).Where("1 = 1").Update("no", "all")
+	if result.Error != nil || result.RowsAffected != 2 {
+		t.Fatalf("WithoutTenant Update = %d, %v, want 2 rows", result.RowsAffected, result.Error)
+	}
+}
+
+func TestTenantIDFromContext(t *testing.T) {
+	if id := TenantIDFrom(context.Background()); id != "" {
+		t.Fatalf("TenantIDFrom(Background) = %q", id)
+	}
+	if id := TenantIDFrom(TenantContext("t1")); id != "t1" {
+		t.Fatalf("TenantIDFrom(TenantContext) = %q, want t1", id)
+	}
+	// 兼容旧代码写入的字符串键
+	legacy := context.WithValue(context.Background(), "tenant_id", "t2")
+	if id := TenantIDFrom(legacy); id != "t2" {
+		t.Fatalf("TenantIDFrom(\"tenant_id\") = %q, want t2", id)
+	}
+	var list []pluginOrder
+	if err := newPluginDB(t).WithContext(legacy).Find(&list).Error; err != nil || len(list) != 1 || list[0].No != "t2-order" {
+		t.Fatalf("旧写法 context 查询 = %+v, %v, want only t2-order", list, err)
+	}
+}
@@ -259,3 +259,9 @@ func GetAllConfig() map[string]map[string]interface{} {
 	}
 	return instance.data
 }
+
+// ConfigPath 返回实际加载的配置文件路径（config.yaml 或 config.ini）
+func ConfigPath() string {
+	once.Do(loadConfig)
+	return configPath
+}
@@ -70,12 +70,36 @@ func FirstLower(s string) string {
 	return strings.ToLower(s[:1]) + s[1:]
 }
 
-// ValidatePhone validatePhone 验证手机号的函数
+// 简单的手机号验证正则表达式，可根据实际需求修改
+var phonePattern = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
+// ValidatePhone validatePhone 验证手机号的函数，已注册为校验规则 phone
 func ValidatePhone(fl validator.FieldLevel) bool {
-	phone := fl.Field().String()
-	// 简单的手机号验证正则表达式，可根据实际需求修改
-	re := regexp.MustCompile(`^1[3-9]\d{9}$`)
-	return re.MatchString(phone)
+	return phonePattern.MatchString(fl.Field().String())
+}
+
+// 身份证号：18 位（末位可为 X）或旧版 15 位
+var idCardPattern = regexp.MustCompile(`^(\d{17}[\dXx]|\d{15})$`)
+
+// ValidateIDCard 验证中国大陆居民身份证号（18 位校验末位校验码），已注册为校验规则 idcard
+func ValidateIDCard(fl validator.FieldLevel) bool {
+	return IsIDCard(fl.Field().String())
+}
+
+// IsIDCard 判断是否为有效的身份证号
+func IsIDCard(id string) bool {
+	if !idCardPattern.MatchString(id) {
+		return false
+	}
+	if len(id) == 15 {
+		return true
+	}
+	weights := []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i, w := range weights {
+		sum += int(id[i]-'0') * w
+	}
+	return "10X98765432"[sum%11] == strings.ToUpper(id[17:])[0]
 }
 
 // ToInt 辅助函数，将字符串转换为整数
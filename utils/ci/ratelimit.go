@@ -0,0 +1,106 @@
+package ci
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	// 内置具名中间件 throttle[:次数/周期]，默认 60/m，按客户端 IP 计数
+	RegisterMiddleware("throttle", func(args ...string) (gin.HandlerFunc, error) {
+		spec := "60/m"
+		if len(args) > 0 && args[0] != "" {
+			spec = args[0]
+		}
+		return RateLimit(spec)
+	})
+}
+
+// ParseRateLimit 解析限流规则：次数/周期，周期支持 s、m、h 或 time.Duration 格式（如 10/s、100/m、5/30s）
+func ParseRateLimit(spec string) (int, time.Duration, error) {
+	countStr, periodStr, found := strings.Cut(strings.TrimSpace(spec), "/")
+	if !found {
+		return 0, 0, fmt.Errorf("限流规则格式错误: %q（应为 次数/周期，如 10/s）", spec)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(countStr))
+	if err != nil || count <= 0 {
+		return 0, 0, fmt.Errorf("限流规则次数错误: %q", spec)
+	}
+	var period time.Duration
+	switch periodStr = strings.TrimSpace(periodStr); periodStr {
+	case "s", "second":
+		period = time.Second
+	case "m", "minute":
+		period = time.Minute
+	case "h", "hour":
+		period = time.Hour
+	default:
+		period, err = time.ParseDuration(periodStr)
+		if err != nil || period <= 0 {
+			return 0, 0, fmt.Errorf("限流规则周期错误: %q", spec)
+		}
+	}
+	return count, period, nil
+}
+
+// rateWindow 单个客户端在当前周期内的计数
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimit 创建按客户端 IP 计数的固定窗口限流中间件，超出时返回 429
+//
+//	limiter, _ := ci.RateLimit("10/s")
+//	R.POST("/api/sms/send", limiter, handler)
+func RateLimit(spec string) (gin.HandlerFunc, error) {
+	limit, period, err := ParseRateLimit(spec)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		mu        sync.Mutex
+		windows   = make(map[string]*rateWindow)
+		lastSweep = time.Now()
+	)
+	return func(c *gin.Context) {
+		now := time.Now()
+		key := c.ClientIP()
+
+		mu.Lock()
+		// 定期清理过期窗口，避免内存随客户端数量增长
+		if now.Sub(lastSweep) > period {
+			for k, w := range windows {
+				if now.Sub(w.start) >= period {
+					delete(windows, k)
+				}
+			}
+			lastSweep = now
+		}
+		w, ok := windows[key]
+		if !ok || now.Sub(w.start) >= period {
+			w = &rateWindow{start: now}
+			windows[key] = w
+		}
+		w.count++
+		exceeded := w.count > limit
+		retryAfter := period - now.Sub(w.start)
+		mu.Unlock()
+
+		if exceeded {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.JSON(429, gin.H{
+				"code": 429,
+				"msg":  "请求过于频繁，请稍后再试",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}, nil
+}
@@ -0,0 +1,186 @@
+package ci
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CertReloader 证书热加载器。
+// 握手时按 reload_interval 检查证书/私钥文件的修改时间，变更后自动重新加载，续签证书无需重启服务。
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	certMod   time.Time
+	keyMod    time.Time
+	lastCheck time.Time
+}
+
+// NewCertReloader 创建证书热加载器，创建时即加载一次证书，失败返回错误
+func NewCertReloader(certFile, keyFile string, interval time.Duration) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload 读取证书文件并记录修改时间
+func (r *CertReloader) reload() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("读取证书文件失败: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("读取私钥文件失败: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("加载证书失败: %w", err)
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.certMod = certInfo.ModTime()
+	r.keyMod = keyInfo.ModTime()
+	r.lastCheck = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+// changed 判断证书或私钥文件是否在上次加载后被修改
+func (r *CertReloader) changed() bool {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return false
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return !certInfo.ModTime().Equal(r.certMod) || !keyInfo.ModTime().Equal(r.keyMod)
+}
+
+// GetCertificate 供 tls.Config.GetCertificate 使用
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	due := time.Since(r.lastCheck) >= r.interval
+	r.mu.RUnlock()
+
+	if due {
+		if r.changed() {
+			// 新证书可能尚未写完整，加载失败时继续使用旧证书
+			if err := r.reload(); err != nil {
+				fmt.Printf("[tls] 证书热加载失败，继续使用旧证书: %v\n", err)
+			} else {
+				fmt.Printf("[tls] 证书已重新加载: %s\n", r.certFile)
+			}
+		}
+		r.mu.Lock()
+		r.lastCheck = time.Now()
+		r.mu.Unlock()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// TLSEnabled 是否开启 HTTPS 监听（config tls.enable）
+func TLSEnabled() bool {
+	return C("tls.enable") == "true"
+}
+
+// TLSHTTP2Enabled 是否在 HTTPS 上启用 HTTP/2（config tls.http2，默认 true）
+func TLSHTTP2Enabled() bool {
+	return C("tls.http2") != "false"
+}
+
+// TLSConfig 根据 [tls] 配置生成 *tls.Config，未开启时返回 nil。
+//
+//	tls.enable           true/false（默认 false）
+//	tls.cert_file        证书文件路径（PEM）
+//	tls.key_file         私钥文件路径（PEM）
+//	tls.min_version      最低 TLS 版本：1.0/1.1/1.2/1.3（默认 1.2）
+//	tls.http2            true/false（默认 true）
+//	tls.reload_interval  证书文件变更检查间隔（秒，默认 60）
+func TLSConfig() (*tls.Config, error) {
+	if !TLSEnabled() {
+		return nil, nil
+	}
+	certFile := strings.TrimSpace(C("tls.cert_file"))
+	keyFile := strings.TrimSpace(C("tls.key_file"))
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("tls.enable=true 时必须配置 tls.cert_file 与 tls.key_file")
+	}
+
+	minVersion, err := parseTLSVersion(C("tls.min_version"))
+	if err != nil {
+		return nil, err
+	}
+
+	interval := 60 * time.Second
+	if sec, err := strconv.Atoi(C("tls.reload_interval")); err == nil && sec > 0 {
+		interval = time.Duration(sec) * time.Second
+	}
+
+	reloader, err := NewCertReloader(certFile, keyFile, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	nextProtos := []string{"http/1.1"}
+	if TLSHTTP2Enabled() {
+		nextProtos = []string{"h2", "http/1.1"}
+	}
+
+	return &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: reloader.GetCertificate,
+		NextProtos:     nextProtos,
+	}, nil
+}
+
+// parseTLSVersion 将配置中的版本号转换为 tls 常量，留空默认 TLS 1.2
+func parseTLSVersion(v string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(v)), "tls") {
+	case "":
+		return tls.VersionTLS12, nil
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("不支持的 tls.min_version: %s（仅支持 1.0/1.1/1.2/1.3）", v)
+	}
+}
+
+// HTTPSRedirectHandler 返回将 HTTP 请求 301 跳转到 HTTPS 的处理器，httpsPort 为 HTTPS 监听端口
+func HTTPSRedirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, req, "https://"+host+req.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}